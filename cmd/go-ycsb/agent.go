@@ -0,0 +1,151 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/cluster"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+	"github.com/spf13/cobra"
+)
+
+var (
+	agentListen string
+	agentName   string
+)
+
+// agentRunner runs the phases sent by the coordinator. The DB is created by
+// the first phase and kept for the following ones, like scenario does.
+type agentRunner struct {
+	dbName   string
+	phase    string
+	p        *properties.Properties
+	workload ycsb.Workload
+	// db is the DB with the retry policy and timeouts of the phase.
+	db ycsb.DB
+}
+
+func (r *agentRunner) Prepare(req *cluster.PhaseRequest) error {
+	p := properties.LoadMap(req.Properties)
+	p.Set(prop.DB, req.DB)
+
+	if globalDB == nil {
+		db, err := newDB(req.DB, p)
+		if err != nil {
+			return err
+		}
+		globalDB = db
+		r.dbName = req.DB
+	} else if req.DB != r.dbName {
+		return fmt.Errorf("the agent runs %s, not %s", r.dbName, req.DB)
+	}
+
+	db, err := phaseDB(p)
+	if err != nil {
+		return err
+	}
+	workload, err := newWorkload(p)
+	if err != nil {
+		return err
+	}
+	if r.workload != nil {
+		r.workload.Close()
+	}
+	r.workload = workload
+	r.phase = req.Phase
+	r.p = p
+	r.db = db
+	return nil
+}
+
+func (r *agentRunner) Run(ctx context.Context) *cluster.PhaseResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-globalContext.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	fmt.Printf("***************** phase %s *****************\n", r.phase)
+	measurement.InitMeasure(r.p)
+	c := client.NewClient(r.p, r.workload, r.db)
+	start := time.Now()
+	c.Run(ctx)
+	elapsed := time.Now().Sub(start)
+	fmt.Printf("Phase %s finished, takes %s\n", r.phase, elapsed)
+	measurement.Output()
+
+	result := &cluster.PhaseResult{Elapsed: elapsed}
+	if err := c.Err(); err != nil {
+		result.Error = err.Error()
+	}
+	hists, err := cluster.EncodeHistograms(measurement.Histograms())
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Histograms = hists
+	return result
+}
+
+func runAgentCommandFunc(cmd *cobra.Command, args []string) {
+	name := agentName
+	if name == "" {
+		host, _ := os.Hostname()
+		name = host + agentListen
+	}
+
+	runner := &agentRunner{}
+	http.Handle("/agent/", cluster.NewAgentHandler(name, runner))
+	http.Handle("/control/", client.ControlHandler())
+	http.Handle("/metrics", client.MetricsHandler())
+	server := &http.Server{Addr: agentListen}
+	go func() {
+		<-globalContext.Done()
+		server.Close()
+	}()
+
+	fmt.Printf("Agent %s waiting for the coordinator on %s\n", name, agentListen)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		util.Fatalf("agent failed %v", err)
+	}
+	if runner.workload != nil {
+		runner.workload.Close()
+	}
+}
+
+func newAgentCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "agent",
+		Short: "Run the phases sent by a go-ycsb coordinator",
+		Args:  cobra.NoArgs,
+		Run:   runAgentCommandFunc,
+	}
+
+	m.Flags().StringVar(&agentListen, "listen", ":7000", "Address to listen to the coordinator on, it also serves the debug and control endpoints")
+	m.Flags().StringVar(&agentName, "name", "", "Name of the agent in the results, default is the host name and listen address")
+	return m
+}
@@ -18,9 +18,12 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/client"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
 	"github.com/spf13/cobra"
 )
 
@@ -49,18 +52,42 @@ func runClientCommandFunc(cmd *cobra.Command, args []string, doTransactions bool
 		}
 	})
 
+	measurement.InitMeasure(globalProps)
+	runClient(globalProps, globalWorkload)
+}
+
+// runClient runs the workload against the global DB with the given properties,
+// prints the final measurements and returns how long the run took. If the run
+// was aborted, the following ones are cancelled and the program exits non-zero.
+func runClient(p *properties.Properties, workload ycsb.Workload) time.Duration {
 	fmt.Println("***************** properties *****************")
-	for key, value := range globalProps.Map() {
+	for key, value := range p.Map() {
 		fmt.Printf("\"%s\"=\"%s\"\n", key, value)
 	}
 	fmt.Println("**********************************************")
 
-	c := client.NewClient(globalProps, globalWorkload, globalDB)
+	db, err := phaseDB(p)
+	if err != nil {
+		util.Fatal(err)
+	}
+	c := client.NewClient(p, workload, db)
 	start := time.Now()
 	c.Run(globalContext)
+	elapsed := time.Now().Sub(start)
 	fmt.Println("**********************************************")
-	fmt.Printf("Run finished, takes %s\n", time.Now().Sub(start))
+	fmt.Printf("Run finished, takes %s\n", elapsed)
 	measurement.Output()
+	recordHistory(p)
+	if err := c.Err(); err != nil {
+		fmt.Println(err)
+		exitCode = 1
+		globalCancel()
+	}
+	if !measurement.AssertionsPassed() {
+		fmt.Println("assertions failed")
+		exitCode = 1
+	}
+	return elapsed
 }
 
 func runLoadCommandFunc(cmd *cobra.Command, args []string) {
@@ -0,0 +1,101 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/pingcap/go-ycsb/pkg/report"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var compareThreshold float64
+
+// describeRun returns the file name of the run, with its label and ID if known.
+func describeRun(r *report.Run) string {
+	if r.RunID == "" {
+		return r.Name
+	}
+	return fmt.Sprintf("%s (label %q, run %s)", r.Name, r.Label, r.RunID)
+}
+
+func formatStat(stat string, v float64) string {
+	if stat == "ops" {
+		return util.FloatToOneString(v)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// compareRows returns one line per delta, and the number of regressions.
+func compareRows(deltas []report.Delta) ([][]string, int) {
+	var regressions int
+	rows := make([][]string, 0, len(deltas))
+	for _, d := range deltas {
+		change := "n/a"
+		if !math.IsNaN(d.Change) {
+			change = fmt.Sprintf("%+.1f%%", d.Change*100)
+		}
+		significance := "n/a"
+		if d.PValue >= 0 {
+			significance = fmt.Sprintf("p=%.3f", d.PValue)
+			if d.Significant() {
+				significance += " significant"
+			} else {
+				significance += " not significant"
+			}
+		}
+		verdict := ""
+		if d.Regression {
+			verdict = "REGRESSION"
+			regressions++
+		}
+		rows = append(rows, []string{d.Operation, d.Stat, formatStat(d.Stat, d.Base), formatStat(d.Stat, d.Candidate), change, significance, verdict})
+	}
+	return rows, regressions
+}
+
+func runCompareCommandFunc(cmd *cobra.Command, args []string) {
+	base, err := report.Load(args[0])
+	if err != nil {
+		util.Fatalf("load %s failed %v", args[0], err)
+	}
+	candidate, err := report.Load(args[1])
+	if err != nil {
+		util.Fatalf("load %s failed %v", args[1], err)
+	}
+
+	fmt.Printf("baseline:  %s\ncandidate: %s\n", describeRun(base), describeRun(candidate))
+	rows, regressions := compareRows(report.Compare(base, candidate, compareThreshold/100))
+	util.RenderTable(os.Stdout, []string{"Operation", "Stat", "Baseline", "Candidate", "Change", "Significance", ""}, rows)
+
+	if regressions > 0 {
+		fmt.Printf("%d stats regressed by more than %s%%\n", regressions, util.FloatToOneString(compareThreshold))
+		exitCode = 1
+	}
+}
+
+func newCompareCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "compare baseline candidate",
+		Short: "Compare the results of two runs, from result documents or interval logs",
+		Args:  cobra.ExactArgs(2),
+		Run:   runCompareCommandFunc,
+	}
+
+	m.Flags().Float64Var(&compareThreshold, "threshold", 5, "Change in percent above which a lower throughput or a higher latency is a regression")
+	return m
+}
@@ -0,0 +1,115 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/cluster"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	coordinatorAgents  []string
+	coordinatorCommand string
+)
+
+func runCoordinatorCommandFunc(cmd *cobra.Command, args []string) {
+	dbName := args[0]
+	if len(coordinatorAgents) == 0 {
+		util.Fatal("--agents is required")
+	}
+
+	var phases []scenarioPhase
+	if scenarioFile != "" {
+		var err error
+		if phases, err = loadScenario(scenarioFile); err != nil {
+			util.Fatalf("load scenario %s failed %v", scenarioFile, err)
+		}
+	} else {
+		if coordinatorCommand != "load" && coordinatorCommand != "run" {
+			util.Fatalf("unknown command %q, must be load or run", coordinatorCommand)
+		}
+		phases = []scenarioPhase{{name: coordinatorCommand, command: coordinatorCommand}}
+	}
+
+	loadGlobalProps()
+	coordinator := cluster.NewCoordinator(coordinatorAgents)
+
+	for i, phase := range phases {
+		if globalContext.Err() != nil {
+			return
+		}
+
+		var p *properties.Properties
+		if phase.overrides != nil {
+			p = phaseProperties(phase)
+		} else {
+			p = properties.NewProperties()
+			p.Merge(globalProps)
+			p.Set(prop.DoTransactions, strconv.FormatBool(phase.command == "run"))
+			p.Set(prop.Command, phase.command)
+		}
+
+		fmt.Printf("***************** phase %s (%s) on %d agents *****************\n", phase.name, phase.command, len(coordinatorAgents))
+		result, err := coordinator.RunPhase(globalContext, dbName, phase.name, p)
+		if err != nil {
+			util.Fatalf("phase %s failed %v", phase.name, err)
+		}
+		for _, agent := range result.Agents {
+			fmt.Printf("Agent %s finished, takes %s\n", agent.Agent, agent.Elapsed)
+		}
+		fmt.Printf("Phase %s finished, takes %s\n", phase.name, result.Elapsed)
+		measurement.OutputHistograms(os.Stdout, p, result.Histograms, result.Elapsed)
+
+		if err := result.Err(); err != nil {
+			fmt.Printf("phase %s aborted: %v\n", phase.name, err)
+			exitCode = 1
+			return
+		}
+
+		if phase.sleep > 0 && i < len(phases)-1 {
+			fmt.Printf("Sleeping %s before next phase\n", phase.sleep)
+			select {
+			case <-globalContext.Done():
+				return
+			case <-time.After(phase.sleep):
+			}
+		}
+	}
+}
+
+func newCoordinatorCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "coordinator db",
+		Short: "YCSB benchmark split between go-ycsb agents, with merged results",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runCoordinatorCommandFunc,
+	}
+
+	m.Flags().StringSliceVar(&coordinatorAgents, "agents", nil, "Comma-separated host:port of the agents, eg: 10.0.0.1:7000,10.0.0.2:7000")
+	m.Flags().StringVar(&coordinatorCommand, "command", "run", "Phase to run when no scenario is given, load or run")
+	m.Flags().StringVarP(&scenarioFile, "scenario", "s", "", "Specify a scenario file to run its phases in lockstep on all agents")
+	m.Flags().StringSliceVarP(&propertyFiles, "property_file", "P", nil, "Spefify a property file")
+	m.Flags().StringArrayVarP(&propertyValues, "prop", "p", nil, "Specify a property value with name=value")
+	m.Flags().StringVar(&tableName, "table", "", "Use the table name instead of the default \""+prop.TableNameDefault+"\"")
+	return m
+}
@@ -0,0 +1,192 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/client"
+	"github.com/pingcap/go-ycsb/pkg/history"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/report"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyPath         string
+	historyName         string
+	historyMetric       string
+	historyLimit        int
+	historyBaselineRuns int
+)
+
+// recordHistory saves the result of the run that just finished in the history
+// store, and compares it with the medians of the previous runs of its series.
+// A failure only warns, the run itself went fine.
+func recordHistory(p *properties.Properties) {
+	path := p.GetString(prop.HistoryPath, "")
+	r := measurement.LastResult()
+	if path == "" || r == nil {
+		return
+	}
+
+	if err := compareWithHistory(path, p, r); err != nil {
+		fmt.Printf("warning: record history in %s failed %v\n", path, err)
+	}
+}
+
+func compareWithHistory(path string, p *properties.Properties, r *measurement.Result) error {
+	store, err := history.Open(path)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	series := history.SeriesOf(os.Getenv(client.BenchmarkNameEnv), r)
+	previous, err := store.Runs(series, r.StartTime, p.GetInt(prop.HistoryBaselineRuns, prop.HistoryBaselineRunsDefault))
+	if err != nil {
+		return err
+	}
+	if err := store.Save(series, r); err != nil {
+		return err
+	}
+
+	fmt.Printf("***************** history %s *****************\n", series)
+	baseline := history.Baseline(previous)
+	if baseline == nil {
+		fmt.Println("First run of the series, no baseline yet")
+		return nil
+	}
+	threshold := p.GetFloat64(prop.HistoryThreshold, prop.HistoryThresholdDefault)
+	deltas := report.Compare(&report.Run{Result: baseline}, &report.Run{Result: r}, threshold/100)
+	rows, regressions := compareRows(deltas)
+	fmt.Printf("Compared with the medians of the %d previous runs\n", len(previous))
+	util.RenderTable(os.Stdout, []string{"Operation", "Stat", "Baseline", "Run", "Change", "Significance", ""}, rows)
+	if regressions > 0 {
+		fmt.Printf("%d stats regressed by more than %s%% from the baseline\n", regressions, util.FloatToOneString(threshold))
+	}
+	return nil
+}
+
+// historyRows returns the runs of the series with the metric, its change from
+// the previous run and from the baseline of the run.
+func historyRows(store *history.Store, series history.Series, op string, stat string, baselineRuns int) ([][]string, error) {
+	runs, err := store.Runs(series, time.Time{}, historyLimit+baselineRuns)
+	if err != nil {
+		return nil, err
+	}
+
+	change := func(v float64, from *measurement.Result) string {
+		if from == nil {
+			return ""
+		}
+		o := from.OperationStats(op)
+		if o == nil {
+			return ""
+		}
+		prev, ok := o.Stat(stat)
+		if !ok || prev == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%+.1f%%", (v-prev)/prev*100)
+	}
+
+	var rows [][]string
+	first := len(runs) - historyLimit
+	if first < 0 {
+		first = 0
+	}
+	for i := first; i < len(runs); i++ {
+		r := runs[i]
+		var value, fromPrev, fromBaseline string
+		if o := r.OperationStats(op); o != nil {
+			if v, ok := o.Stat(stat); ok {
+				value = formatStat(stat, v)
+				if i > 0 {
+					fromPrev = change(v, runs[i-1])
+				}
+				start := i - baselineRuns
+				if start < 0 {
+					start = 0
+				}
+				fromBaseline = change(v, history.Baseline(runs[start:i]))
+			}
+		}
+		start := r.StartTime.Format("2006-01-02 15:04:05")
+		rows = append(rows, []string{series.String(), r.RunID, start, value, fromPrev, fromBaseline})
+	}
+	return rows, nil
+}
+
+func runHistoryCommandFunc(cmd *cobra.Command, args []string) {
+	if historyPath == "" {
+		util.Fatal("--path is required")
+	}
+	i := strings.LastIndex(historyMetric, ".")
+	if i <= 0 {
+		util.Fatalf("invalid metric %q, want <OP>.<stat>", historyMetric)
+	}
+	if historyBaselineRuns < 1 {
+		util.Fatalf("invalid baseline runs %d, want at least 1", historyBaselineRuns)
+	}
+	op, stat := historyMetric[:i], historyMetric[i+1:]
+	if _, ok := (&measurement.OperationResult{Count: 1}).Stat(stat); !ok {
+		util.Fatalf("unknown stat %q", stat)
+	}
+
+	store, err := history.Open(historyPath)
+	if err != nil {
+		util.Fatalf("open %s failed %v", historyPath, err)
+	}
+	defer store.Close()
+
+	allSeries, err := store.Series()
+	if err != nil {
+		util.Fatalf("read %s failed %v", historyPath, err)
+	}
+	var rows [][]string
+	for _, series := range allSeries {
+		if historyName != "" && series.Name != historyName {
+			continue
+		}
+		seriesRows, err := historyRows(store, series, op, stat, historyBaselineRuns)
+		if err != nil {
+			util.Fatalf("read %s failed %v", historyPath, err)
+		}
+		rows = append(rows, seriesRows...)
+	}
+	util.RenderTable(os.Stdout, []string{"Series", "Run", "Start", op + "." + stat, "From previous", "From baseline"}, rows)
+}
+
+func newHistoryCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "history",
+		Short: "List the runs kept in a history store with the trend of a metric",
+		Args:  cobra.NoArgs,
+		Run:   runHistoryCommandFunc,
+	}
+
+	m.Flags().StringVar(&historyPath, "path", "", "History store, the "+prop.HistoryPath+" of the runs")
+	m.Flags().StringVar(&historyName, "name", "", "Only list the series of this benchmark")
+	m.Flags().StringVar(&historyMetric, "metric", "TOTAL.ops", "Metric to follow, <OP>.<stat> with a stat of the assert.* properties")
+	m.Flags().IntVar(&historyLimit, "limit", 20, "Number of latest runs listed per series")
+	m.Flags().IntVar(&historyBaselineRuns, "baseline-runs", prop.HistoryBaselineRunsDefault, "Number of previous runs whose medians are the baseline of a run, like "+prop.HistoryBaselineRuns)
+	return m
+}
@@ -32,7 +32,6 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/pingcap/go-ycsb/pkg/client"
-	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 	_ "github.com/pingcap/go-ycsb/pkg/workload"
@@ -92,9 +91,74 @@ var (
 	globalDB       ycsb.DB
 	globalWorkload ycsb.Workload
 	globalProps    *properties.Properties
+
+	// exitCode is the status the program exits with once everything is closed.
+	exitCode int
 )
 
-func initialGlobal(dbName string, onProperties func()) {
+func newWorkload(p *properties.Properties) (ycsb.Workload, error) {
+	workloadName := p.GetString(prop.Workload, "core")
+	workloadCreator := ycsb.GetWorkloadCreator(workloadName)
+	if workloadCreator == nil {
+		return nil, fmt.Errorf("workload %s is not registered", workloadName)
+	}
+
+	workload, err := workloadCreator.Create(p)
+	if err != nil {
+		return nil, fmt.Errorf("create workload %s failed %v", workloadName, err)
+	}
+	return workload, nil
+}
+
+func createWorkload(p *properties.Properties) ycsb.Workload {
+	workload, err := newWorkload(p)
+	if err != nil {
+		util.Fatal(err)
+	}
+	return workload
+}
+
+// newDB creates the DB wrapped to measure and retry its operations.
+func newDB(dbName string, p *properties.Properties) (ycsb.DB, error) {
+	dbCreator := ycsb.GetDBCreator(dbName)
+	if dbCreator == nil {
+		return nil, fmt.Errorf("%s is not registered", dbName)
+	}
+	// check the retry policy and timeouts before connecting
+	if _, err := wrapDB(nil, p); err != nil {
+		return nil, err
+	}
+	db, err := dbCreator.Create(p)
+	if err != nil {
+		return nil, fmt.Errorf("create db %s failed %v", dbName, err)
+	}
+	return wrapDB(db, p)
+}
+
+// wrapDB wraps db to measure its operations and retry them as set by p.
+func wrapDB(db ycsb.DB, p *properties.Properties) (ycsb.DB, error) {
+	retry, err := client.NewRetryPolicy(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry policy: %v", err)
+	}
+	timeouts, err := client.NewTimeouts(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeouts: %v", err)
+	}
+	return client.DbWrapper{DB: db, Retry: retry, Timeouts: timeouts}, nil
+}
+
+// phaseDB returns the global DB with the retry policy and timeouts of p, the
+// properties of a phase.
+func phaseDB(p *properties.Properties) (ycsb.DB, error) {
+	if w, ok := globalDB.(client.DbWrapper); ok {
+		return wrapDB(w.DB, p)
+	}
+	return globalDB, nil
+}
+
+// loadGlobalProps loads the property files and values given on the command line.
+func loadGlobalProps() {
 	globalProps = properties.NewProperties()
 	if len(propertyFiles) > 0 {
 		globalProps = properties.MustLoadFiles(propertyFiles, properties.UTF8, false)
@@ -108,41 +172,35 @@ func initialGlobal(dbName string, onProperties func()) {
 		globalProps.Set(seps[0], seps[1])
 	}
 
+	if len(tableName) == 0 {
+		tableName = globalProps.GetString(prop.TableName, prop.TableNameDefault)
+	}
+	if _, _, err := globalProps.Set(prop.TableName, tableName); err != nil {
+		panic(err)
+	}
+}
+
+func initialGlobal(dbName string, onProperties func()) {
+	loadGlobalProps()
+	globalProps.Set(prop.DB, dbName)
+
 	if onProperties != nil {
 		onProperties()
 	}
 
 	addr := globalProps.GetString(prop.DebugPprof, prop.DebugPprofDefault)
+	http.Handle("/control/", client.ControlHandler())
+	http.Handle("/metrics", client.MetricsHandler())
 	go func() {
 		http.ListenAndServe(addr, nil)
 	}()
 
-	measurement.InitMeasure(globalProps)
+	globalWorkload = createWorkload(globalProps)
 
-	if len(tableName) == 0 {
-		tableName = globalProps.GetString(prop.TableName, prop.TableNameDefault)
-	}
 	var err error
-
-	if _, _, err = globalProps.Set(prop.TableName, tableName); err != nil {
-		panic(err)
-	}
-
-	workloadName := globalProps.GetString(prop.Workload, "core")
-	workloadCreator := ycsb.GetWorkloadCreator(workloadName)
-
-	if globalWorkload, err = workloadCreator.Create(globalProps); err != nil {
-		util.Fatalf("create workload %s failed %v", workloadName, err)
-	}
-
-	dbCreator := ycsb.GetDBCreator(dbName)
-	if dbCreator == nil {
-		util.Fatalf("%s is not registered", dbName)
-	}
-	if globalDB, err = dbCreator.Create(globalProps); err != nil {
-		util.Fatalf("create db %s failed %v", dbName, err)
+	if globalDB, err = newDB(dbName, globalProps); err != nil {
+		util.Fatal(err)
 	}
-	globalDB = client.DbWrapper{globalDB}
 }
 
 func main() {
@@ -183,6 +241,13 @@ func main() {
 		newShellCommand(),
 		newLoadCommand(),
 		newRunCommand(),
+		newScenarioCommand(),
+		newSweepCommand(),
+		newCompareCommand(),
+		newHistoryCommand(),
+		newReportCommand(),
+		newCoordinatorCommand(),
+		newAgentCommand(),
 	)
 
 	cobra.EnablePrefixMatching = true
@@ -201,4 +266,5 @@ func main() {
 	}
 
 	closeDone <- struct{}{}
+	os.Exit(exitCode)
 }
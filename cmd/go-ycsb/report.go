@@ -0,0 +1,79 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/pingcap/go-ycsb/pkg/report"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportResultFile string
+	reportOutputFile string
+)
+
+func runReportCommandFunc(cmd *cobra.Command, args []string) {
+	run, err := report.Load(args[0])
+	if err != nil {
+		util.Fatalf("load %s failed %v", args[0], err)
+	}
+	if run.Intervals == nil {
+		util.Fatalf("%s is not an interval log", args[0])
+	}
+	// the result document has the run and its properties, and the errors
+	if reportResultFile != "" {
+		doc, err := report.Load(reportResultFile)
+		if err != nil {
+			util.Fatalf("load %s failed %v", reportResultFile, err)
+		}
+		if doc.Intervals != nil {
+			util.Fatalf("%s is not a result document", reportResultFile)
+		}
+		run.Result = doc.Result
+	}
+
+	f, err := os.Create(reportOutputFile)
+	if err != nil {
+		util.Fatalf("create %s failed %v", reportOutputFile, err)
+	}
+	w := bufio.NewWriter(f)
+	if err := report.WriteHTML(w, run); err != nil {
+		util.Fatalf("write %s failed %v", reportOutputFile, err)
+	}
+	if err := w.Flush(); err != nil {
+		util.Fatalf("write %s failed %v", reportOutputFile, err)
+	}
+	if err := f.Close(); err != nil {
+		util.Fatalf("write %s failed %v", reportOutputFile, err)
+	}
+	fmt.Printf("Report written to %s\n", reportOutputFile)
+}
+
+func newReportCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "report interval-log",
+		Short: "Write a self-contained HTML report with the charts of an interval log",
+		Args:  cobra.ExactArgs(1),
+		Run:   runReportCommandFunc,
+	}
+
+	m.Flags().StringVar(&reportResultFile, "result", "", "Result document of the run, for its summary and properties")
+	m.Flags().StringVarP(&reportOutputFile, "output", "o", "report.html", "HTML file written")
+	return m
+}
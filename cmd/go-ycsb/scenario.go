@@ -0,0 +1,178 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// Scenario file keys. A scenario file is a property file listing the phase
+// names in order, every other key of a phase is a property override:
+//
+//	phases=load,run
+//	phase.load.command=load
+//	phase.load.threadcount=16
+//	phase.run.command=run
+//	phase.run.threadcount=64
+//	phase.run.target=20000
+//	phase.run.maxexecutiontime=10m
+//	phase.run.sleep=30s
+const (
+	scenarioPhases       = "phases"
+	scenarioPhasePrefix  = "phase."
+	scenarioPhaseCommand = "command"
+	scenarioPhaseSleep   = "sleep"
+)
+
+var scenarioFile string
+
+// scenarioPhase is one load or run step of a scenario.
+type scenarioPhase struct {
+	name    string
+	command string
+	// sleep is the pause after the phase before the next one starts.
+	sleep     time.Duration
+	overrides *properties.Properties
+}
+
+func loadScenario(path string) ([]scenarioPhase, error) {
+	p, err := properties.LoadFile(path, properties.UTF8)
+	if err != nil {
+		return nil, err
+	}
+
+	names := strings.Split(p.GetString(scenarioPhases, ""), ",")
+	phases := make([]scenarioPhase, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		overrides := p.FilterStripPrefix(scenarioPhasePrefix + name + ".")
+		phase := scenarioPhase{
+			name:    name,
+			command: overrides.GetString(scenarioPhaseCommand, "run"),
+		}
+		if phase.command != "load" && phase.command != "run" {
+			return nil, fmt.Errorf("phase %s: unknown command %q, must be load or run", name, phase.command)
+		}
+		if v, ok := overrides.Get(scenarioPhaseSleep); ok {
+			if phase.sleep, err = time.ParseDuration(v); err != nil {
+				return nil, fmt.Errorf("phase %s: invalid sleep %q: %v", name, v, err)
+			}
+		}
+		overrides.Delete(scenarioPhaseCommand)
+		overrides.Delete(scenarioPhaseSleep)
+		phase.overrides = overrides
+
+		phases = append(phases, phase)
+	}
+
+	if len(phases) == 0 {
+		return nil, fmt.Errorf("no phases defined in %s, set %q", path, scenarioPhases)
+	}
+	return phases, nil
+}
+
+// phaseProperties returns the global properties with the phase overrides applied.
+func phaseProperties(phase scenarioPhase) *properties.Properties {
+	p := properties.NewProperties()
+	p.Merge(globalProps)
+	p.Merge(phase.overrides)
+
+	p.Set(prop.DoTransactions, strconv.FormatBool(phase.command == "run"))
+	p.Set(prop.Command, phase.command)
+
+	if _, ok := phase.overrides.Get(prop.Label); !ok {
+		label := phase.name
+		if base := globalProps.GetString(prop.Label, ""); base != "" {
+			label = base + "-" + phase.name
+		}
+		p.Set(prop.Label, label)
+	}
+	return p
+}
+
+func runScenarioCommandFunc(cmd *cobra.Command, args []string) {
+	dbName := args[0]
+
+	phases, err := loadScenario(scenarioFile)
+	if err != nil {
+		util.Fatalf("load scenario %s failed %v", scenarioFile, err)
+	}
+
+	// The measurements are set up by every phase, with its own output files.
+	initialGlobal(dbName, func() {
+		// The DB is created once for all phases, let it see the first phase.
+		globalProps.Set(prop.DoTransactions, strconv.FormatBool(phases[0].command == "run"))
+		globalProps.Set(prop.Command, phases[0].command)
+	})
+
+	// the phases may set their own retry policy and timeouts
+	for _, phase := range phases {
+		if _, err := phaseDB(phaseProperties(phase)); err != nil {
+			util.Fatalf("phase %s: %v", phase.name, err)
+		}
+	}
+
+	for i, phase := range phases {
+		if globalContext.Err() != nil {
+			return
+		}
+
+		p := phaseProperties(phase)
+		measurement.SetOutputPhase(p, phase.name)
+		fmt.Printf("***************** phase %s (%s) *****************\n", phase.name, phase.command)
+		measurement.InitMeasure(p)
+		workload := createWorkload(p)
+		runClient(p, workload)
+		workload.Close()
+		fmt.Printf("***************** phase %s finished *****************\n", phase.name)
+
+		if phase.sleep > 0 && i < len(phases)-1 {
+			fmt.Printf("Sleeping %s before next phase\n", phase.sleep)
+			select {
+			case <-globalContext.Done():
+				return
+			case <-time.After(phase.sleep):
+			}
+		}
+	}
+}
+
+func newScenarioCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "scenario db",
+		Short: "YCSB multi-phase benchmark described by a scenario file",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runScenarioCommandFunc,
+	}
+
+	m.Flags().StringVarP(&scenarioFile, "scenario", "s", "", "Specify the scenario file with the ordered phases")
+	m.MarkFlagRequired("scenario")
+	m.Flags().StringSliceVarP(&propertyFiles, "property_file", "P", nil, "Spefify a property file")
+	m.Flags().StringArrayVarP(&propertyValues, "prop", "p", nil, "Specify a property value with name=value")
+	m.Flags().StringVar(&tableName, "table", "", "Use the table name instead of the default \""+prop.TableNameDefault+"\"")
+	return m
+}
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"github.com/chzyer/readline"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
 
@@ -45,6 +46,7 @@ var shellContext context.Context
 func runShellCommandFunc(cmd *cobra.Command, args []string) {
 	dbName := args[0]
 	initialGlobal(dbName, nil)
+	measurement.InitMeasure(globalProps)
 
 	shellContext = globalWorkload.InitThread(globalContext, 0, 1)
 	shellContext = globalDB.InitThread(shellContext, 0, 1)
@@ -0,0 +1,162 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sweepThreads     []int
+	sweepTargets     []int
+	sweepPercentiles []string
+	sweepSleep       time.Duration
+)
+
+func sweepHeader(percentiles []float64) []string {
+	header := []string{"Step", "Operation", "Count", "OPS"}
+	for _, per := range percentiles {
+		header = append(header, fmt.Sprintf("%sth(us)", strconv.FormatFloat(per, 'f', -1, 64)))
+	}
+	return header
+}
+
+// sweepRows returns one result line per operation of the step that just
+// finished, its operations having been measured for elapsed.
+func sweepRows(step string, elapsed time.Duration, percentiles []float64) [][]string {
+	hists := measurement.Histograms()
+	ops := make([]string, 0, len(hists))
+	for op := range hists {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	rows := make([][]string, 0, len(ops))
+	for _, op := range ops {
+		hist := hists[op]
+		row := []string{
+			step,
+			op,
+			util.IntToString(hist.TotalCount()),
+			util.FloatToOneString(float64(hist.TotalCount()) / elapsed.Seconds()),
+		}
+		for _, per := range percentiles {
+			row = append(row, util.IntToString(hist.ValueAtPercentile(per)))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func runSweepCommandFunc(cmd *cobra.Command, args []string) {
+	dbName := args[0]
+
+	var key string
+	var values []int
+	switch {
+	case len(sweepThreads) > 0 && len(sweepTargets) > 0:
+		util.Fatal("only one of --threads and --targets can be swept")
+	case len(sweepThreads) > 0:
+		key, values = prop.ThreadCount, sweepThreads
+	case len(sweepTargets) > 0:
+		key, values = prop.Target, sweepTargets
+	default:
+		util.Fatal("--threads or --targets is required")
+	}
+
+	percentiles := make([]float64, 0, len(sweepPercentiles))
+	for _, v := range sweepPercentiles {
+		per, err := strconv.ParseFloat(v, 64)
+		if err != nil || per <= 0 || per > 100 {
+			util.Fatalf("invalid percentile %q", v)
+		}
+		percentiles = append(percentiles, per)
+	}
+
+	initialGlobal(dbName, func() {
+		globalProps.Set(prop.DoTransactions, "true")
+		globalProps.Set(prop.Command, "run")
+	})
+
+	lines := [][]string{}
+	for i, value := range values {
+		if globalContext.Err() != nil {
+			break
+		}
+
+		name := fmt.Sprintf("%s=%d", key, value)
+		p := properties.NewProperties()
+		p.Merge(globalProps)
+		p.Set(key, strconv.Itoa(value))
+		measurement.SetOutputPhase(p, fmt.Sprintf("%s-%d", key, value))
+
+		fmt.Printf("***************** sweep step %s *****************\n", name)
+		measurement.InitMeasure(p)
+		workload := createWorkload(p)
+		start := time.Now()
+		end := start.Add(runClient(p, workload))
+		workload.Close()
+		// the throughput leaves the warm-up out
+		lines = append(lines, sweepRows(name, end.Sub(measurement.MeasureStart()), percentiles)...)
+
+		if sweepSleep > 0 && i < len(values)-1 {
+			select {
+			case <-globalContext.Done():
+			case <-time.After(sweepSleep):
+			}
+		}
+	}
+
+	fmt.Println("***************** sweep results *****************")
+	header := sweepHeader(percentiles)
+	outputStyle := globalProps.GetString(prop.OutputStyle, util.OutputStylePlain)
+	switch outputStyle {
+	case util.OutputStylePlain:
+		util.RenderString(os.Stdout, "%-16s - %s\n", header, lines)
+	case util.OutputStyleJson:
+		util.RenderJson(os.Stdout, header, lines)
+	case util.OutputStyleTable:
+		util.RenderTable(os.Stdout, header, lines)
+	default:
+		util.Fatalf("unsupported outputstyle: %s", outputStyle)
+	}
+}
+
+func newSweepCommand() *cobra.Command {
+	m := &cobra.Command{
+		Use:   "sweep db",
+		Short: "YCSB run benchmark repeated over thread counts or targets",
+		Args:  cobra.MinimumNArgs(1),
+		Run:   runSweepCommandFunc,
+	}
+
+	m.Flags().StringSliceVarP(&propertyFiles, "property_file", "P", nil, "Spefify a property file")
+	m.Flags().StringArrayVarP(&propertyValues, "prop", "p", nil, "Specify a property value with name=value")
+	m.Flags().StringVar(&tableName, "table", "", "Use the table name instead of the default \""+prop.TableNameDefault+"\"")
+	m.Flags().IntSliceVar(&sweepThreads, "threads", nil, "Comma-separated thread counts to run, eg: 1,2,4,8")
+	m.Flags().IntSliceVar(&sweepTargets, "targets", nil, "Comma-separated targets (ops/s) to run, eg: 1000,2000,4000")
+	m.Flags().StringSliceVar(&sweepPercentiles, "percentiles", []string{"50", "99", "99.9"}, "Percentiles to report for each step")
+	m.Flags().DurationVar(&sweepSleep, "sleep", 0, "Pause between two steps")
+	return m
+}
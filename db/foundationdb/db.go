@@ -19,6 +19,7 @@ package foundationdb
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"time"
@@ -93,6 +94,13 @@ func (db *fDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
 	return ctx
 }
 
+// InitSharedThread implements ycsb.SharedThreadDB. The database handle is safe
+// for concurrent use, so the in-flight operations of a worker need no own state
+// when the worker doesn't use the futures of the ycsb.AsyncDB methods.
+func (db *fDB) InitSharedThread(ctx context.Context, _ int, _ int, _ int) context.Context {
+	return ctx
+}
+
 func (db *fDB) CleanupThread(ctx context.Context) {
 }
 
@@ -485,6 +493,181 @@ func (db *fDB) BatchDelete(ctx context.Context, table string, keys []string) err
 	return err
 }
 
+// setReadVersion sets the cached read version on tr, if enabled.
+func (db *fDB) setReadVersion(tr fdb.Transaction) {
+	if !db.useCachedReadVersions {
+		return
+	}
+	if !db.isNewVersionNeeded() {
+		tr.SetReadVersion(db.cachedReadVersion)
+		return
+	}
+	fresh := tr.GetReadVersion().MustGet()
+	db.cachedReadVersion = fresh
+	db.readVersionCachedAt = time.Now()
+	tr.SetReadVersion(fresh)
+}
+
+// transactAsync is the async Transact. issue issues the reads and writes of
+// the transaction as futures without blocking, and returns the function waiting
+// for them. done is called with its result once the transaction committed,
+// from a goroutine waiting for the futures, or with the error that can't be
+// retried.
+func (db *fDB) transactAsync(issue func(tr fdb.Transaction) func() (interface{}, error), done func(interface{}, error)) {
+	tr, err := db.db.CreateTransaction()
+	if err != nil {
+		done(nil, err)
+		return
+	}
+	db.setReadVersion(tr)
+	wait := issue(tr)
+
+	go func() {
+		for {
+			ret, err := wait()
+			if err == nil {
+				err = tr.Commit().Get()
+			}
+			if err == nil {
+				done(ret, nil)
+				return
+			}
+
+			var fdbErr fdb.Error
+			if errors.As(err, &fdbErr) {
+				// OnError resets the transaction if err can be retried
+				err = tr.OnError(fdbErr).Get()
+			}
+			if err != nil {
+				if os.Getenv("FDB_PRINT_ERRORS") != "" {
+					fmt.Println("Got fdb error: ", err)
+				}
+				done(nil, err)
+				return
+			}
+			db.setReadVersion(tr)
+			wait = issue(tr)
+		}
+	}()
+}
+
+// ReadAsync implements ycsb.AsyncDB.
+func (db *fDB) ReadAsync(ctx context.Context, table string, key string, fields []string, done func(map[string][]byte, error)) {
+	rowKey := db.getRowKey(table, key)
+	db.transactAsync(func(tr fdb.Transaction) func() (interface{}, error) {
+		if db.drReadEnabled {
+			tr.Options().SetReadLockAware()
+		}
+		f := tr.Get(fdb.Key(rowKey))
+		return func() (interface{}, error) {
+			return f.Get()
+		}
+	}, func(row interface{}, err error) {
+		if err != nil || row.([]byte) == nil {
+			done(nil, err)
+			return
+		}
+		done(db.r.Decode(row.([]byte), fields))
+	})
+}
+
+// UpdateAsync implements ycsb.AsyncDB.
+func (db *fDB) UpdateAsync(ctx context.Context, table string, key string, values map[string][]byte, done func(error)) {
+	rowKey := db.getRowKey(table, key)
+	db.transactAsync(func(tr fdb.Transaction) func() (interface{}, error) {
+		if db.drReadEnabled {
+			tr.Options().SetReadLockAware()
+		}
+		f := tr.Get(fdb.Key(rowKey))
+		return func() (interface{}, error) {
+			row, err := f.Get()
+			if err != nil || row == nil {
+				return nil, err
+			}
+
+			data, err := db.r.Decode(row, nil)
+			if err != nil {
+				return nil, err
+			}
+			for field, value := range values {
+				data[field] = value
+			}
+
+			buf, err := db.r.Encode(nil, data)
+			if err != nil {
+				return nil, err
+			}
+			tr.Set(fdb.Key(rowKey), buf)
+			return nil, nil
+		}
+	}, func(_ interface{}, err error) {
+		done(err)
+	})
+}
+
+// InsertAsync implements ycsb.AsyncDB.
+func (db *fDB) InsertAsync(ctx context.Context, table string, key string, values map[string][]byte, done func(error)) {
+	buf, err := db.r.Encode(nil, values)
+	if err != nil {
+		done(err)
+		return
+	}
+
+	rowKey := db.getRowKey(table, key)
+	db.transactAsync(func(tr fdb.Transaction) func() (interface{}, error) {
+		tr.Set(fdb.Key(rowKey), buf)
+		return func() (interface{}, error) {
+			return nil, nil
+		}
+	}, func(_ interface{}, err error) {
+		done(err)
+	})
+}
+
+// DeleteAsync implements ycsb.AsyncDB.
+func (db *fDB) DeleteAsync(ctx context.Context, table string, key string, done func(error)) {
+	rowKey := db.getRowKey(table, key)
+	db.transactAsync(func(tr fdb.Transaction) func() (interface{}, error) {
+		tr.Clear(fdb.Key(rowKey))
+		return func() (interface{}, error) {
+			return nil, nil
+		}
+	}, func(_ interface{}, err error) {
+		done(err)
+	})
+}
+
+// IsRetryable implements the ycsb.RetryableDB interface. Transact already
+// retries most errors itself, these are the ones that can still escape it.
+func (db *fDB) IsRetryable(err error) bool {
+	var fdbErr fdb.Error
+	if !errors.As(err, &fdbErr) {
+		return false
+	}
+	switch fdbErr.Code {
+	case 1004, // timed_out
+		1007, // transaction_too_old
+		1009, // future_version
+		1020, // not_committed
+		1021, // commit_unknown_result
+		1031, // transaction_timed_out
+		1037, // process_behind
+		1213: // tag_throttled
+		return true
+	}
+	return false
+}
+
+// ClassifyError implements the ycsb.ErrorClassifier interface, FDB errors are
+// classified by their code.
+func (db *fDB) ClassifyError(err error) string {
+	var fdbErr fdb.Error
+	if !errors.As(err, &fdbErr) {
+		return ""
+	}
+	return fmt.Sprintf("fdb_%d", fdbErr.Code)
+}
+
 type fdbCreator struct {
 }
 
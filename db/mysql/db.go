@@ -20,6 +20,7 @@ import (
 	"database/sql"
 	"database/sql/driver"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 	"sync/atomic"
@@ -505,6 +506,19 @@ func (db *mysqlDB) Analyze(ctx context.Context, table string) error {
 	return err
 }
 
+// ClassifyError implements the ycsb.ErrorClassifier interface, MySQL errors
+// are classified by their number, eg: mysql_1213 for a deadlock.
+func (db *mysqlDB) ClassifyError(err error) string {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return fmt.Sprintf("mysql_%d", mysqlErr.Number)
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return "connection"
+	}
+	return ""
+}
+
 func init() {
 	ycsb.RegisterDBCreator("mysql", mysqlCreator{name: "mysql"})
 	ycsb.RegisterDBCreator("tidb", mysqlCreator{name: "tidb"})
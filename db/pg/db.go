@@ -17,6 +17,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -24,7 +25,7 @@ import (
 	"github.com/pingcap/go-ycsb/pkg/util"
 
 	// pg package
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
@@ -353,6 +354,17 @@ func (db *pgDB) Delete(ctx context.Context, table string, key string) error {
 	return db.execQuery(ctx, query, key)
 }
 
+// ClassifyError implements the ycsb.ErrorClassifier interface, PostgreSQL
+// errors are classified by their SQLSTATE, eg: sqlstate_40001 for a
+// serialization failure.
+func (db *pgDB) ClassifyError(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return "sqlstate_" + string(pqErr.Code)
+	}
+	return ""
+}
+
 func init() {
 	ycsb.RegisterDBCreator("pg", pgCreator{})
 	ycsb.RegisterDBCreator("postgresql", pgCreator{})
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
@@ -349,6 +351,16 @@ func (db *s3DB) Delete(ctx context.Context, table string, key string) error {
 	return err
 }
 
+// ClassifyError implements the ycsb.ErrorClassifier interface, failed requests
+// are classified by their HTTP status, eg: http_503.
+func (db *s3DB) ClassifyError(err error) string {
+	var respErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &respErr) {
+		return fmt.Sprintf("http_%d", respErr.HTTPStatusCode())
+	}
+	return ""
+}
+
 // composeObjectKey builds an S3 object key using table as prefix.
 func (db *s3DB) composeObjectKey(table, key string) string {
 	if table == "" {
@@ -0,0 +1,118 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+type abortSample struct {
+	at    time.Time
+	hists map[string]*hdrhistogram.Histogram
+}
+
+// abortPolicy checks the error rate and the latency of the last window of the
+// run against the abort.* thresholds.
+type abortPolicy struct {
+	errorRate float64
+	p99       int64
+	window    time.Duration
+	// samples are the measurements taken at every check, the oldest one being
+	// the base of the window.
+	samples []abortSample
+}
+
+// newAbortPolicy returns nil if no abort threshold is set. The thresholds are
+// checked against the measurement histograms, set up before.
+func newAbortPolicy(p *properties.Properties) (*abortPolicy, error) {
+	a := &abortPolicy{
+		errorRate: p.GetFloat64(prop.AbortErrorRate, 0),
+		p99:       p.GetInt64(prop.AbortP99, 0),
+	}
+	if a.errorRate <= 0 && a.p99 <= 0 {
+		return nil, nil
+	}
+	if !measurement.HasHistograms() {
+		return nil, fmt.Errorf("the histogram measurement type is required, add it to %s", prop.MeasurementType)
+	}
+	if a.errorRate > 1 {
+		return nil, fmt.Errorf("%s is a fraction within (0, 1], got %v", prop.AbortErrorRate, a.errorRate)
+	}
+
+	v := p.GetString(prop.AbortWindow, prop.AbortWindowDefault)
+	window, err := time.ParseDuration(v)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid %s %q", prop.AbortWindow, v)
+	}
+	a.window = window
+	return a, nil
+}
+
+// start sets the beginning of the first window, once the warm-up is over.
+func (a *abortPolicy) start(now time.Time) {
+	a.samples = []abortSample{{at: now}}
+}
+
+// check adds the current measurements and returns why the run must be
+// aborted, or an empty string.
+func (a *abortPolicy) check(now time.Time, hists map[string]*hdrhistogram.Histogram) string {
+	a.samples = append(a.samples, abortSample{at: now, hists: hists})
+	// keep the newest sample that is at least one window old as the base
+	for len(a.samples) > 2 && !a.samples[1].at.After(now.Add(-a.window)) {
+		a.samples = a.samples[1:]
+	}
+	base := a.samples[0]
+	if now.Sub(base.at) < a.window {
+		// not a full window yet
+		return ""
+	}
+	window := now.Sub(base.at).Round(time.Second)
+
+	var errors, total int64
+	var latency *hdrhistogram.Histogram
+	for op, hist := range hists {
+		switch {
+		case strings.HasSuffix(op, "_ERROR"), strings.HasSuffix(op, "_TIMEOUT"):
+			errors += measurement.HistogramDelta(hist, base.hists[op]).TotalCount()
+		case op == "TOTAL":
+			latency = measurement.HistogramDelta(hist, base.hists[op])
+		case op == measurement.IntendedPrefix+"TOTAL" && latency == nil:
+			latency = measurement.HistogramDelta(hist, base.hists[op])
+		}
+	}
+	if latency != nil {
+		total = latency.TotalCount()
+	}
+
+	if a.errorRate > 0 && errors > 0 {
+		if rate := float64(errors) / float64(errors+total); rate >= a.errorRate {
+			return fmt.Sprintf("error rate %.2f%% over the last %s reached %s %.2f%%",
+				rate*100, window, prop.AbortErrorRate, a.errorRate*100)
+		}
+	}
+	if a.p99 > 0 && total > 0 {
+		if p99 := latency.ValueAtQuantile(99); p99 > a.p99 {
+			return fmt.Sprintf("p99 latency %dus over the last %s exceeded %s %dus",
+				p99, window, prop.AbortP99, a.p99)
+		}
+	}
+	return ""
+}
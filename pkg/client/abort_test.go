@@ -0,0 +1,107 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// abortHists accumulates recorded latencies like the measurement histograms.
+type abortHists map[string]*hdrhistogram.Histogram
+
+func (h abortHists) record(op string, latency int64, count int64) {
+	if h[op] == nil {
+		h[op] = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	}
+	h[op].RecordValues(latency, count)
+}
+
+func (h abortHists) copy() map[string]*hdrhistogram.Histogram {
+	c := make(map[string]*hdrhistogram.Histogram, len(h))
+	for op, hist := range h {
+		c[op] = hdrhistogram.Import(hist.Export())
+	}
+	return c
+}
+
+func TestAbortPolicy(t *testing.T) {
+	p := properties.NewProperties()
+	p.Set(prop.AbortErrorRate, "0.1")
+	p.Set(prop.AbortP99, "5000")
+	p.Set(prop.AbortWindow, "20s")
+	measurement.InitMeasure(p)
+	a, err := newAbortPolicy(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	a.start(start)
+	hists := abortHists{}
+	tests := []struct {
+		// what happens during the 10s before the check
+		ok, errors int64
+		latency    int64
+		want       string
+	}{
+		// a bad first interval is not a full window yet
+		{100, 50, 100, ""},
+		// 50 errors out of 1050 ops over the last 20s
+		{900, 0, 100, ""},
+		// the first interval leaves the window, 20 errors out of 1020
+		{100, 20, 100, ""},
+		// 220 errors out of 1120
+		{800, 200, 100, "error rate 19.64% over the last 20s"},
+		// 200 errors out of 2000, the threshold is inclusive
+		{1000, 0, 100, "error rate 10.00% over the last 20s"},
+		{1000, 0, 100, ""},
+		{1000, 0, 10000, "p99 latency 10007us over the last 20s"},
+	}
+	for i, test := range tests {
+		hists.record("READ", test.latency, test.ok)
+		hists.record("TOTAL", test.latency, test.ok)
+		hists.record("READ_ERROR", test.latency, test.errors)
+
+		got := a.check(start.Add(time.Duration(i+1)*10*time.Second), hists.copy())
+		if (test.want == "") != (got == "") || !strings.HasPrefix(got, test.want) {
+			t.Errorf("check %d: got %q, want %q", i, got, test.want)
+		}
+	}
+}
+
+func TestAbortPolicyDisabled(t *testing.T) {
+	a, err := newAbortPolicy(properties.NewProperties())
+	if a != nil || err != nil {
+		t.Errorf("got %v, %v without thresholds, want nil", a, err)
+	}
+}
+
+func TestAbortPolicyWithoutHistogram(t *testing.T) {
+	p := properties.NewProperties()
+	p.Set(prop.AbortP99, "5000")
+	p.Set(prop.MeasurementType, "hdrlog")
+	p.Set(prop.MeasurementHdrLogOutputFile, filepath.Join(t.TempDir(), "run.hlog"))
+	measurement.InitMeasure(p)
+	if _, err := newAbortPolicy(p); err == nil || !strings.Contains(err.Error(), "histogram") {
+		t.Errorf("got %v with hdrlog only, want the histogram measurement type required", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// asyncDB issues the record operations of a worker through a ycsb.AsyncDB,
+// keeping up to depth of them in flight. Read, Update, Insert and Delete only
+// wait for a free slot, the operations are measured once they complete and
+// their errors are not returned to the workload. Reads return no row. The
+// other operations are done synchronously by the DbWrapper.
+type asyncDB struct {
+	DbWrapper
+	async ycsb.AsyncDB
+	// slots has an element for every operation in flight.
+	slots chan struct{}
+}
+
+// asyncDBOf returns the AsyncDB wrapped by db, if it implements it.
+func asyncDBOf(db ycsb.DB) (DbWrapper, ycsb.AsyncDB, bool) {
+	w, ok := db.(DbWrapper)
+	if !ok {
+		return DbWrapper{}, nil, false
+	}
+	a, ok := w.DB.(ycsb.AsyncDB)
+	return w, a, ok
+}
+
+func newAsyncDB(db DbWrapper, async ycsb.AsyncDB, depth int) *asyncDB {
+	return &asyncDB{DbWrapper: db, async: async, slots: make(chan struct{}, depth)}
+}
+
+// start issues op once a slot is free.
+func (db *asyncDB) start(ctx context.Context, op string, fn func(ctx context.Context, done func(error))) error {
+	select {
+	case db.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	// the worker moves on to the intended start of its next operation
+	ctx = measurement.DetachIntendedStart(ctx)
+	db.doAsync(ctx, op, fn, func() { <-db.slots })
+	return nil
+}
+
+// wait returns once the operations in flight completed.
+func (db *asyncDB) wait() {
+	for i := 0; i < cap(db.slots); i++ {
+		db.slots <- struct{}{}
+	}
+	for i := 0; i < cap(db.slots); i++ {
+		<-db.slots
+	}
+}
+
+// copyValues copies values, the workload reuses them once the call returned.
+func copyValues(values map[string][]byte) map[string][]byte {
+	c := make(map[string][]byte, len(values))
+	for field, value := range values {
+		c[field] = append([]byte(nil), value...)
+	}
+	return c
+}
+
+func (db *asyncDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	return nil, db.start(ctx, "READ", func(ctx context.Context, done func(error)) {
+		db.async.ReadAsync(ctx, table, key, fields, func(_ map[string][]byte, err error) {
+			done(err)
+		})
+	})
+}
+
+func (db *asyncDB) Update(ctx context.Context, table string, key string, values map[string][]byte) error {
+	values = copyValues(values)
+	return db.start(ctx, "UPDATE", func(ctx context.Context, done func(error)) {
+		db.async.UpdateAsync(ctx, table, key, values, done)
+	})
+}
+
+func (db *asyncDB) Insert(ctx context.Context, table string, key string, values map[string][]byte) error {
+	values = copyValues(values)
+	return db.start(ctx, "INSERT", func(ctx context.Context, done func(error)) {
+		db.async.InsertAsync(ctx, table, key, values, done)
+	})
+}
+
+func (db *asyncDB) Delete(ctx context.Context, table string, key string) error {
+	return db.start(ctx, "DELETE", func(ctx context.Context, done func(error)) {
+		db.async.DeleteAsync(ctx, table, key, done)
+	})
+}
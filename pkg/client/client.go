@@ -19,30 +19,52 @@ import (
 	"math/rand"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/measurement"
 	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
 	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
 type worker struct {
-	p               *properties.Properties
-	workDB          ycsb.DB
-	workload        ycsb.Workload
-	doTransactions  bool
-	doBatch         bool
-	batchSize       int
-	opCount         int64
-	targetOpsPerMs  float64
-	threadID        int
-	targetOpsTickNs int64
-	opsDone         int64
-}
-
-func newWorker(p *properties.Properties, threadID int, threadCount int, workload ycsb.Workload, db ycsb.DB) *worker {
+	c              *Client
+	p              *properties.Properties
+	workDB         ycsb.DB
+	workload       ycsb.Workload
+	doTransactions bool
+	doBatch        bool
+	batchSize      int
+	// opCount is accessed atomically, the operations are split again between
+	// the running workers when their number changes.
+	opCount     int64
+	threadID    int
+	threadCount int
+	// depth is the number of operations the worker keeps in flight.
+	depth int
+	// opsDone is accessed atomically, it is read by the control API.
+	opsDone int64
+	// opsIssued is accessed atomically, it counts the operations started
+	// after the warm-up so that lanes don't go past opCount.
+	opsIssued int64
+	// throttleMu serializes the lanes waiting for their schedule slot.
+	throttleMu sync.Mutex
+	// stopCtx is done when the run or only this worker is stopped.
+	stopCtx context.Context
+	stop    context.CancelFunc
+	// scheduleVersion is the client schedule version intendedStart follows.
+	scheduleVersion int64
+	// intendedStart is the time the throttle scheduled the next operation for,
+	// zero while the operations are not throttled, as during the warm-up.
+	intendedStart time.Time
+}
+
+func newWorker(c *Client, threadID int, threadCount int) *worker {
+	p := c.p
 	w := new(worker)
+	w.c = c
 	w.p = p
 	w.doTransactions = p.GetBool(prop.DoTransactions, true)
 	w.batchSize = p.GetInt(prop.BatchSize, prop.DefaultBatchSize)
@@ -50,20 +72,15 @@ func newWorker(p *properties.Properties, threadID int, threadCount int, workload
 		w.doBatch = true
 	}
 	w.threadID = threadID
-	w.workload = workload
-	w.workDB = db
-
-	var totalOpCount int64
-	if w.doTransactions {
-		totalOpCount = p.GetInt64(prop.OperationCount, 0)
-	} else {
-		if _, ok := p.Get(prop.InsertCount); ok {
-			totalOpCount = p.GetInt64(prop.InsertCount, 0)
-		} else {
-			totalOpCount = p.GetInt64(prop.RecordCount, 0)
-		}
+	w.threadCount = threadCount
+	w.depth = p.GetInt(prop.AsyncDepth, prop.AsyncDepthDefault)
+	if w.depth < 1 {
+		w.depth = 1
 	}
+	w.workload = c.workload
+	w.workDB = c.db
 
+	totalOpCount := c.totalOpCount
 	if totalOpCount < int64(threadCount) {
 		fmt.Printf("totalOpCount(%s/%s/%s): %d should be bigger than threadCount: %d",
 			prop.OperationCount,
@@ -80,60 +97,160 @@ func newWorker(p *properties.Properties, threadID int, threadCount int, workload
 		w.opCount++
 	}
 
-	targetPerThreadPerms := float64(-1)
-	if v := p.GetInt64(prop.Target, 0); v > 0 {
-		targetPerThread := float64(v) / float64(threadCount)
-		targetPerThreadPerms = targetPerThread / 1000.0
-	}
-
-	if targetPerThreadPerms > 0 {
-		w.targetOpsPerMs = targetPerThreadPerms
-		w.targetOpsTickNs = int64(1000000.0 / w.targetOpsPerMs)
-	}
+	w.stopCtx, w.stop = context.WithCancel(c.stopCtx)
 
 	return w
 }
 
-func (w *worker) throttle(ctx context.Context, startTime time.Time) {
-	if w.targetOpsPerMs <= 0 {
+// totalOpCount returns the number of operations of the run.
+func totalOpCount(p *properties.Properties) int64 {
+	if p.GetBool(prop.DoTransactions, true) {
+		return p.GetInt64(prop.OperationCount, 0)
+	}
+	if _, ok := p.Get(prop.InsertCount); ok {
+		return p.GetInt64(prop.InsertCount, 0)
+	}
+	return p.GetInt64(prop.RecordCount, 0)
+}
+
+// throttle waits until the slot of the next operation, after opsCount operations
+// were done, as given by the target schedule.
+func (w *worker) throttle(opsCount int) {
+	schedule, threadCount, version, changed := w.c.throttleState()
+	if schedule == nil {
+		// unthrottled operations are measured from their actual start
+		w.intendedStart = time.Time{}
 		return
 	}
 
-	d := time.Duration(w.opsDone * w.targetOpsTickNs)
-	d = startTime.Add(d).Sub(time.Now())
+	if version != w.scheduleVersion || w.intendedStart.IsZero() {
+		// the target or the thread count changed, or the warm-up just ended,
+		// follow the rate from now on
+		w.scheduleVersion = version
+		w.intendedStart = time.Now()
+	}
+
+	next := scheduleAdvance(schedule, threadCount, w.intendedStart.Sub(w.c.start), float64(opsCount))
+	w.intendedStart = w.c.start.Add(next)
+	d := w.intendedStart.Sub(time.Now())
 	if d < 0 {
 		return
 	}
 	select {
-	case <-ctx.Done():
+	case <-w.stopCtx.Done():
+	case <-changed:
 	case <-time.After(d):
 	}
 }
 
+// run executes operations until opCount is reached or the worker is stopped,
+// keeping up to depth of them in flight. Operations themselves use ctx, so the
+// in-flight ones are allowed to finish.
 func (w *worker) run(ctx context.Context) {
-	// spread the thread operation out so they don't all hit the DB at the same time
-	if w.targetOpsPerMs > 0.0 && w.targetOpsPerMs <= 1.0 {
-		time.Sleep(time.Duration(rand.Int63n(w.targetOpsTickNs)))
+	schedule, threadCount, version, _ := w.c.throttleState()
+	if schedule != nil {
+		// spread the thread operation out so they don't all hit the DB at the same time
+		targetPerThreadPerS := scheduleTarget(schedule, time.Since(w.c.start)) / float64(threadCount)
+		if targetPerThreadPerS <= 1000.0 {
+			select {
+			case <-w.stopCtx.Done():
+			case <-time.After(time.Duration(rand.Int63n(int64(float64(time.Second) / targetPerThreadPerS)))):
+			}
+		}
+	}
+	w.throttleMu.Lock()
+	w.scheduleVersion = version
+	// The warm-up is not throttled, the schedule starts once it ends.
+	if schedule != nil && measurement.IsWarmUpFinished() {
+		w.intendedStart = time.Now()
+	}
+	w.throttleMu.Unlock()
+
+	// The lanes of the worker record their measurements into its shard.
+	ctx = measurement.WithShard(ctx)
+	defer measurement.CleanupShard(ctx)
+
+	// Bindings implementing AsyncDB keep the operations in flight from the
+	// worker goroutine. Their reads return no row, so they are not used to
+	// check the data integrity, and batches are done synchronously.
+	if w.depth > 1 && !w.doBatch && !w.p.GetBool(prop.DataIntegrity, prop.DataIntegrityDefault) {
+		if db, async, ok := asyncDBOf(w.workDB); ok {
+			w.runLane(ctx, 0, 1, newAsyncDB(db, async, w.depth), false)
+			return
+		}
+	}
+
+	// Bindings implementing SharedThreadDB share one thread state between the
+	// lanes, the others get a thread state per lane.
+	sharedDB := false
+	if w.depth > 1 {
+		if db, ok := sharedThreadDB(w.workDB); ok {
+			ctx = db.InitSharedThread(ctx, w.threadID, w.threadCount, w.depth)
+			defer w.workDB.CleanupThread(ctx)
+			sharedDB = true
+		}
+	}
+
+	var wg sync.WaitGroup
+	for lane := 1; lane < w.depth; lane++ {
+		wg.Add(1)
+		go func(lane int) {
+			defer wg.Done()
+			w.runLane(ctx, lane, w.depth, w.workDB, sharedDB)
+		}(lane)
+	}
+	w.runLane(ctx, 0, w.depth, w.workDB, sharedDB)
+	wg.Wait()
+}
+
+// runLane issues operations through db one after the other, it is one of the
+// lanes of the worker. The workload state is per lane, the lanes of a worker
+// share its operation count and schedule.
+func (w *worker) runLane(ctx context.Context, lane int, lanes int, db ycsb.DB, sharedDB bool) {
+	// Lanes are seen as threads by the workload, and by the DB unless it is shared.
+	laneID := w.threadID*lanes + lane
+	laneCount := w.threadCount * lanes
+	ctx = w.workload.InitThread(ctx, laneID, laneCount)
+	defer w.workload.CleanupThread(ctx)
+	if !sharedDB {
+		ctx = w.workDB.InitThread(ctx, laneID, laneCount)
+		defer w.workDB.CleanupThread(ctx)
+	}
+
+	if async, ok := db.(*asyncDB); ok {
+		// the operations in flight are measured into the shard
+		defer async.wait()
 	}
 
-	startTime := time.Now()
+	w.throttleMu.Lock()
+	intendedStart := w.intendedStart
+	w.throttleMu.Unlock()
+	ctx = measurement.WithIntendedStart(ctx, &intendedStart)
+
+	opsCount := 1
+	if w.doBatch {
+		opsCount = w.batchSize
+	}
+	// Lanes reserve their operations before issuing them, so together they stop
+	// where a single lane checking opsDone would.
+	for {
+		opCount := atomic.LoadInt64(&w.opCount)
+		if atomic.AddInt64(&w.opsIssued, int64(opsCount))-int64(opsCount) >= opCount && opCount != 0 {
+			break
+		}
 
-	for w.opCount == 0 || w.opsDone < w.opCount {
 		var err error
-		opsCount := 1
 		if w.doTransactions {
 			if w.doBatch {
-				err = w.workload.DoBatchTransaction(ctx, w.batchSize, w.workDB)
-				opsCount = w.batchSize
+				err = w.workload.DoBatchTransaction(ctx, w.batchSize, db)
 			} else {
-				err = w.workload.DoTransaction(ctx, w.workDB)
+				err = w.workload.DoTransaction(ctx, db)
 			}
 		} else {
 			if w.doBatch {
-				err = w.workload.DoBatchInsert(ctx, w.batchSize, w.workDB)
-				opsCount = w.batchSize
+				err = w.workload.DoBatchInsert(ctx, w.batchSize, db)
 			} else {
-				err = w.workload.DoInsert(ctx, w.workDB)
+				err = w.workload.DoInsert(ctx, db)
 			}
 		}
 
@@ -142,12 +259,18 @@ func (w *worker) run(ctx context.Context) {
 		}
 
 		if measurement.IsWarmUpFinished() {
-			w.opsDone += int64(opsCount)
-			w.throttle(ctx, startTime)
+			atomic.AddInt64(&w.opsDone, int64(opsCount))
+			w.throttleMu.Lock()
+			w.throttle(opsCount)
+			intendedStart = w.intendedStart
+			w.throttleMu.Unlock()
+		} else {
+			// operations done during the warm-up don't count
+			atomic.AddInt64(&w.opsIssued, -int64(opsCount))
 		}
 
 		select {
-		case <-ctx.Done():
+		case <-w.stopCtx.Done():
 			return
 		default:
 		}
@@ -159,6 +282,32 @@ type Client struct {
 	p        *properties.Properties
 	workload ycsb.Workload
 	db       ycsb.DB
+
+	// The fields below belong to the current run.
+	ctx   context.Context
+	start time.Time
+	// stopCtx is done when the run is stopped, by maxexecutiontime or Stop.
+	stopCtx     context.Context
+	stop        context.CancelFunc
+	wg          sync.WaitGroup
+	threadCount int
+	// totalOpCount is the number of operations of the run, split between the
+	// workers.
+	totalOpCount int64
+
+	mu sync.RWMutex
+	// schedule is nil if the run is not throttled.
+	schedule        targetSchedule
+	scheduleVersion int64
+	// scheduleChanged is closed and replaced whenever the schedule or the
+	// number of workers changes, to wake up the throttled workers.
+	scheduleChanged chan struct{}
+	// workers are the running workers, allWorkers also has the finished ones.
+	workers      []*worker
+	allWorkers   []*worker
+	nextThreadID int
+	// abortReason is set if the run was aborted by an abort.* threshold.
+	abortReason string
 }
 
 // NewClient returns a client with the given workload and DB.
@@ -167,12 +316,238 @@ func NewClient(p *properties.Properties, workload ycsb.Workload, db ycsb.DB) *Cl
 	return &Client{p: p, workload: workload, db: db}
 }
 
+func (c *Client) throttleState() (targetSchedule, int, int64, <-chan struct{}) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.schedule, len(c.workers), c.scheduleVersion, c.scheduleChanged
+}
+
+// scheduleChangedLocked must be called with c.mu held.
+func (c *Client) scheduleChangedLocked() {
+	c.scheduleVersion++
+	close(c.scheduleChanged)
+	c.scheduleChanged = make(chan struct{})
+}
+
+// startWorkerLocked must be called with c.mu held.
+func (c *Client) startWorkerLocked() {
+	threadID := c.nextThreadID
+	c.nextThreadID++
+	// Workers added while running get their share of the operations left when
+	// the workers are rebalanced.
+	w := newWorker(c, threadID, c.threadCount)
+	c.workers = append(c.workers, w)
+	c.allWorkers = append(c.allWorkers, w)
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+
+		w.run(c.ctx)
+		c.removeWorker(w)
+	}()
+}
+
+func (c *Client) removeWorker(w *worker) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, other := range c.workers {
+		if other == w {
+			c.workers = append(c.workers[:i], c.workers[i+1:]...)
+			c.scheduleChangedLocked()
+			return
+		}
+	}
+}
+
+// ThreadCount returns the number of running workers.
+func (c *Client) ThreadCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.workers)
+}
+
+// SetThreadCount starts or stops workers until n of them are running. Stopped
+// workers finish their in-flight operation first.
+func (c *Client) SetThreadCount(n int) error {
+	if n < 1 {
+		return fmt.Errorf("thread count must be at least 1, got %d", n)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Once all workers are gone Run may be done waiting for them.
+	if len(c.workers) == 0 || c.stopCtx.Err() != nil {
+		return fmt.Errorf("the run is not in progress")
+	}
+	for len(c.workers) < n {
+		c.startWorkerLocked()
+	}
+	for len(c.workers) > n {
+		w := c.workers[len(c.workers)-1]
+		c.workers = c.workers[:len(c.workers)-1]
+		w.stop()
+	}
+	c.rebalanceLocked()
+	c.scheduleChangedLocked()
+	return nil
+}
+
+// rebalanceLocked splits the operations not issued yet between the running
+// workers, so that changing the thread count doesn't change the operations of
+// the run. It must be called with c.mu held.
+func (c *Client) rebalanceLocked() {
+	issued := func(w *worker) int64 {
+		n, opCount := atomic.LoadInt64(&w.opsIssued), atomic.LoadInt64(&w.opCount)
+		// lanes reserve an operation past opCount before they stop
+		if n > opCount {
+			return opCount
+		}
+		return n
+	}
+
+	remaining := c.totalOpCount
+	for _, w := range c.allWorkers {
+		remaining -= issued(w)
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	count := int64(len(c.workers))
+	for i, w := range c.workers {
+		share := remaining / count
+		if int64(i) < remaining%count {
+			share++
+		}
+		if share == 0 && issued(w) == 0 {
+			// an opCount of 0 would not stop the worker
+			w.stop()
+			continue
+		}
+		atomic.StoreInt64(&w.opCount, issued(w)+share)
+	}
+}
+
+// Target returns the current total target in operations per second, or 0 if
+// the run is not throttled.
+func (c *Client) Target() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.schedule == nil {
+		return 0
+	}
+	return scheduleTarget(c.schedule, time.Since(c.start))
+}
+
+// SetTarget replaces the target schedule by a constant target in operations
+// per second. A target <= 0 removes the throttling.
+func (c *Client) SetTarget(target float64) {
+	c.mu.Lock()
+
+	if target > 0 {
+		c.schedule = constantSchedule(target)
+	} else {
+		c.schedule = nil
+	}
+	c.scheduleChangedLocked()
+	workers := append([]*worker(nil), c.workers...)
+	c.mu.Unlock()
+
+	if target <= 0 {
+		// throttling workers hold their throttleMu while taking c.mu, so
+		// their intended start times are cleared after releasing it
+		for _, w := range workers {
+			w.throttleMu.Lock()
+			w.intendedStart = time.Time{}
+			w.throttleMu.Unlock()
+		}
+	}
+}
+
+// OpsDone returns the number of operations done after the warm-up.
+func (c *Client) OpsDone() int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var n int64
+	for _, w := range c.allWorkers {
+		n += atomic.LoadInt64(&w.opsDone)
+	}
+	return n
+}
+
+// Elapsed returns the time since the run started.
+func (c *Client) Elapsed() time.Duration {
+	return time.Since(c.start)
+}
+
+// abort stops the run like Stop and records why.
+func (c *Client) abort(reason string) {
+	c.mu.Lock()
+	c.abortReason = reason
+	c.mu.Unlock()
+
+	fmt.Printf("Aborting the run: %s\n", reason)
+	c.stop()
+}
+
+// Err returns an error if the run was aborted because an abort.* threshold
+// was crossed.
+func (c *Client) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.abortReason != "" {
+		return fmt.Errorf("run aborted: %s", c.abortReason)
+	}
+	return nil
+}
+
+// Stop stops the run gracefully: the workers finish their in-flight operations
+// and Run returns as if maxexecutiontime elapsed.
+func (c *Client) Stop() {
+	c.stop()
+}
+
 // Run runs the workload to the target DB, and blocks until all workers end.
+// If maxexecutiontime is set, workers stop issuing new operations once it
+// elapses and Run returns after the in-flight operations finish.
 func (c *Client) Run(ctx context.Context) {
-	var wg sync.WaitGroup
-	threadCount := c.p.GetInt(prop.ThreadCount, 1)
+	c.ctx = ctx
+	c.threadCount = c.p.GetInt(prop.ThreadCount, 1)
+	c.totalOpCount = totalOpCount(c.p)
+
+	c.stopCtx, c.stop = context.WithCancel(ctx)
+	if v := c.p.GetString(prop.MaxExecutiontime, ""); v != "" {
+		maxExecutionTime, err := time.ParseDuration(v)
+		if err != nil {
+			util.Fatalf("invalid %s %q: %v", prop.MaxExecutiontime, v, err)
+		}
+		// stop still cancels the timeout context, derived from its own
+		var cancel context.CancelFunc
+		c.stopCtx, cancel = context.WithTimeout(c.stopCtx, maxExecutionTime)
+		defer cancel()
+	}
+	defer c.stop()
+
+	schedule, err := newTargetSchedule(c.p)
+	if err != nil {
+		util.Fatalf("invalid target schedule: %v", err)
+	}
+	abort, err := newAbortPolicy(c.p)
+	if err != nil {
+		util.Fatalf("invalid abort thresholds: %v", err)
+	}
+	status, err := newStatusReporter(c, c.p)
+	if err != nil {
+		util.Fatalf("invalid status reporter: %v", err)
+	}
+	c.schedule = schedule
+	c.scheduleChanged = make(chan struct{})
+	c.start = time.Now()
 
-	wg.Add(threadCount)
 	measureCtx, measureCancel := context.WithCancel(ctx)
 	measureCh := make(chan struct{}, 1)
 	go func() {
@@ -183,13 +558,19 @@ func (c *Client) Run(ctx context.Context) {
 		if c.p.GetBool(prop.DoTransactions, true) {
 			dur := c.p.GetInt64(prop.WarmUpTime, 0)
 			select {
-			case <-ctx.Done():
+			case <-c.stopCtx.Done():
 				return
 			case <-time.After(time.Duration(dur) * time.Second):
 			}
 		}
 		// finish warming up
 		measurement.EnableWarmUp(false)
+		if abort != nil {
+			abort.start(time.Now())
+		}
+		if status != nil {
+			status.start(time.Now())
+		}
 
 		dur := c.p.GetInt64(prop.LogInterval, 10)
 		t := time.NewTicker(time.Duration(dur) * time.Second)
@@ -198,27 +579,37 @@ func (c *Client) Run(ctx context.Context) {
 		for {
 			select {
 			case <-t.C:
+				if target := c.Target(); target > 0 {
+					fmt.Printf("Current target: %.1f ops/sec\n", target)
+				}
 				measurement.Summary()
+				if status != nil {
+					status.report(os.Stdout, time.Now())
+				}
+				if abort != nil {
+					if reason := abort.check(time.Now(), measurement.Histograms()); reason != "" {
+						c.abort(reason)
+					}
+				}
 			case <-measureCtx.Done():
 				return
 			}
 		}
 	}()
 
-	for i := 0; i < threadCount; i++ {
-		go func(threadId int) {
-			defer wg.Done()
-
-			w := newWorker(c.p, threadId, threadCount, c.workload, c.db)
-			ctx := c.workload.InitThread(ctx, threadId, threadCount)
-			ctx = c.db.InitThread(ctx, threadId, threadCount)
-			w.run(ctx)
-			c.db.CleanupThread(ctx)
-			c.workload.CleanupThread(ctx)
-		}(i)
+	c.mu.Lock()
+	for i := 0; i < c.threadCount; i++ {
+		c.startWorkerLocked()
 	}
+	c.mu.Unlock()
 
-	wg.Wait()
+	setActiveClient(c)
+	c.wg.Wait()
+	setActiveClient(nil)
+
+	if c.stopCtx.Err() == context.DeadlineExceeded {
+		fmt.Printf("Reached %s %s, workers stopped\n", prop.MaxExecutiontime, c.p.GetString(prop.MaxExecutiontime, ""))
+	}
 	if !c.p.GetBool(prop.DoTransactions, true) {
 		// when loading is finished, try to analyze table if possible.
 		if analyzeDB, ok := c.db.(ycsb.AnalyzeDB); ok {
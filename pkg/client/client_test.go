@@ -0,0 +1,337 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// sleepWorkload issues one read for every transaction.
+type sleepWorkload struct {
+	done     int64
+	cleanups int64
+}
+
+func (s *sleepWorkload) Close() error { return nil }
+
+func (s *sleepWorkload) InitThread(ctx context.Context, _ int, _ int) context.Context { return ctx }
+
+func (s *sleepWorkload) CleanupThread(_ context.Context) { atomic.AddInt64(&s.cleanups, 1) }
+
+func (s *sleepWorkload) Load(_ context.Context, _ ycsb.DB, _ int64) error { return nil }
+
+func (s *sleepWorkload) DoInsert(ctx context.Context, db ycsb.DB) error {
+	return s.DoTransaction(ctx, db)
+}
+
+func (s *sleepWorkload) DoBatchInsert(ctx context.Context, _ int, db ycsb.DB) error {
+	return s.DoTransaction(ctx, db)
+}
+
+func (s *sleepWorkload) DoTransaction(ctx context.Context, db ycsb.DB) error {
+	_, err := db.Read(ctx, "t", "k", nil)
+	atomic.AddInt64(&s.done, 1)
+	return err
+}
+
+func (s *sleepWorkload) DoBatchTransaction(ctx context.Context, _ int, db ycsb.DB) error {
+	return s.DoTransaction(ctx, db)
+}
+
+// sleepDB sleeps for delay on every operation, ignoring cancellation so
+// that in-flight operations always complete.
+type sleepDB struct {
+	delay time.Duration
+}
+
+func (d sleepDB) Close() error { return nil }
+
+func (d sleepDB) InitThread(ctx context.Context, _ int, _ int) context.Context { return ctx }
+
+func (d sleepDB) CleanupThread(_ context.Context) {}
+
+func (d sleepDB) Read(_ context.Context, _ string, _ string, _ []string) (map[string][]byte, error) {
+	time.Sleep(d.delay)
+	return nil, nil
+}
+
+func (d sleepDB) Scan(_ context.Context, _ string, _ string, _ int, _ []string) ([]map[string][]byte, error) {
+	time.Sleep(d.delay)
+	return nil, nil
+}
+
+func (d sleepDB) Update(_ context.Context, _ string, _ string, _ map[string][]byte) error {
+	time.Sleep(d.delay)
+	return nil
+}
+
+func (d sleepDB) Insert(_ context.Context, _ string, _ string, _ map[string][]byte) error {
+	time.Sleep(d.delay)
+	return nil
+}
+
+func (d sleepDB) Delete(_ context.Context, _ string, _ string) error {
+	time.Sleep(d.delay)
+	return nil
+}
+
+func newTestProperties(kvs ...string) *properties.Properties {
+	p := properties.NewProperties()
+	p.Set(prop.OperationCount, "1000000000")
+	p.Set(prop.ThreadCount, "4")
+	for i := 0; i+1 < len(kvs); i += 2 {
+		p.Set(kvs[i], kvs[i+1])
+	}
+	measurement.InitMeasure(p)
+	return p
+}
+
+func TestRunMaxExecutionTime(t *testing.T) {
+	p := newTestProperties(prop.MaxExecutiontime, "200ms")
+	workload := &sleepWorkload{}
+	c := NewClient(p, workload, DbWrapper{DB: sleepDB{delay: 10 * time.Millisecond}})
+
+	start := time.Now()
+	c.Run(context.Background())
+	elapsed := time.Since(start)
+
+	if elapsed < 200*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("run took %s, want about 200ms", elapsed)
+	}
+	if atomic.LoadInt64(&workload.done) == 0 {
+		t.Errorf("no operation was done")
+	}
+	if n := atomic.LoadInt64(&workload.cleanups); n != 4 {
+		t.Errorf("CleanupThread called %d times, want 4", n)
+	}
+}
+
+func TestRunIntendedLatency(t *testing.T) {
+	p := newTestProperties(
+		prop.ThreadCount, "1",
+		prop.OperationCount, "20",
+		prop.Target, "100",
+		prop.MeasurementLatencyMode, measurement.LatencyModeBoth,
+	)
+	c := NewClient(p, &sleepWorkload{}, DbWrapper{DB: sleepDB{delay: 30 * time.Millisecond}})
+	c.Run(context.Background())
+
+	hists := measurement.Histograms()
+	read, intended := hists["READ"], hists[measurement.IntendedPrefix+"READ"]
+	if read == nil || intended == nil {
+		t.Fatalf("missing READ or INTENDED_READ histogram, got %v", hists)
+	}
+	if read.TotalCount() != 20 || intended.TotalCount() != 20 {
+		t.Errorf("got %d READ and %d INTENDED_READ, want 20", read.TotalCount(), intended.TotalCount())
+	}
+	// The DB is three times slower than the target, so the last operations
+	// are queued for hundreds of milliseconds behind their schedule.
+	if intended.Max() < 2*read.Max() {
+		t.Errorf("intended max %dus should include the queueing delay, op max is %dus", intended.Max(), read.Max())
+	}
+}
+
+func TestRunIntendedLatencyUnthrottled(t *testing.T) {
+	p := newTestProperties(
+		prop.ThreadCount, "1",
+		prop.OperationCount, "20",
+		prop.MeasurementLatencyMode, measurement.LatencyModeBoth,
+	)
+	c := NewClient(p, &sleepWorkload{}, DbWrapper{DB: sleepDB{delay: 10 * time.Millisecond}})
+	c.Run(context.Background())
+
+	hists := measurement.Histograms()
+	read, intended := hists["READ"], hists[measurement.IntendedPrefix+"READ"]
+	if read == nil || intended == nil {
+		t.Fatalf("missing READ or INTENDED_READ histogram, got %v", hists)
+	}
+	// Without a schedule the operations are measured from their actual start,
+	// not from the start of the worker.
+	if intended.Max() > read.Max()+5000 {
+		t.Errorf("intended max %dus should be about the op max %dus", intended.Max(), read.Max())
+	}
+}
+
+func TestRunIntendedLatencyAfterWarmUp(t *testing.T) {
+	p := newTestProperties(
+		prop.ThreadCount, "1",
+		prop.Target, "100",
+		prop.WarmUpTime, "1",
+		prop.MaxExecutiontime, "1500ms",
+		prop.MeasurementLatencyMode, measurement.LatencyModeBoth,
+	)
+	c := NewClient(p, &sleepWorkload{}, DbWrapper{DB: sleepDB{delay: time.Millisecond}})
+	c.Run(context.Background())
+
+	intended := measurement.Histograms()[measurement.IntendedPrefix+"READ"]
+	if intended == nil || intended.TotalCount() == 0 {
+		t.Fatalf("no INTENDED_READ measured after the warm-up")
+	}
+	// The DB keeps up with the target, the first operations after the warm-up
+	// are not charged the warm-up period.
+	if intended.Max() > 100000 {
+		t.Errorf("intended max %dus includes the warm-up", intended.Max())
+	}
+}
+
+func TestSetThreadCountKeepsOperationCount(t *testing.T) {
+	for _, counts := range [][]int{{2, 5}, {4, 1}, {2, 5, 1}} {
+		p := newTestProperties(
+			prop.ThreadCount, fmt.Sprint(counts[0]),
+			prop.OperationCount, "200",
+		)
+		workload := &sleepWorkload{}
+		c := NewClient(p, workload, DbWrapper{DB: sleepDB{delay: 5 * time.Millisecond}})
+		go func() {
+			for _, n := range counts[1:] {
+				time.Sleep(30 * time.Millisecond)
+				if err := c.SetThreadCount(n); err != nil {
+					t.Errorf("set thread count %d: %v", n, err)
+				}
+			}
+		}()
+		c.Run(context.Background())
+
+		if n := atomic.LoadInt64(&workload.done); n != 200 {
+			t.Errorf("thread counts %v: %d operations done, want 200", counts, n)
+		}
+	}
+}
+
+// sharedSleepDB is a sleepDB sharing one thread state between the in-flight
+// operations of a worker.
+type sharedSleepDB struct {
+	sleepDB
+	sharedInits *int64
+}
+
+func (d sharedSleepDB) InitSharedThread(ctx context.Context, _ int, _ int, _ int) context.Context {
+	atomic.AddInt64(d.sharedInits, 1)
+	return ctx
+}
+
+func TestRunAsyncDepth(t *testing.T) {
+	for _, shared := range []bool{false, true} {
+		p := newTestProperties(
+			prop.ThreadCount, "2",
+			prop.OperationCount, "41",
+			prop.AsyncDepth, "4",
+		)
+		workload := &sleepWorkload{}
+		var sharedInits int64
+		var db ycsb.DB = sleepDB{delay: 20 * time.Millisecond}
+		if shared {
+			db = sharedSleepDB{sleepDB: db.(sleepDB), sharedInits: &sharedInits}
+		}
+		c := NewClient(p, workload, DbWrapper{DB: db})
+
+		start := time.Now()
+		c.Run(context.Background())
+		elapsed := time.Since(start)
+
+		// 2 workers do 21 and 20 operations of 20ms, 4 at a time.
+		if elapsed > 300*time.Millisecond {
+			t.Errorf("shared %v: run took %s, want about 120ms", shared, elapsed)
+		}
+		if n := atomic.LoadInt64(&workload.done); n != 41 {
+			t.Errorf("shared %v: %d operations done, want 41", shared, n)
+		}
+		if n := c.OpsDone(); n != 41 {
+			t.Errorf("shared %v: %d operations counted, want 41", shared, n)
+		}
+		if n := atomic.LoadInt64(&workload.cleanups); n != 8 {
+			t.Errorf("shared %v: workload CleanupThread called %d times, want one per lane", shared, n)
+		}
+		if shared && sharedInits != 2 {
+			t.Errorf("InitSharedThread called %d times, want one per worker", sharedInits)
+		}
+	}
+}
+
+// asyncSleepDB completes every async operation after delay, without a
+// goroutine waiting for it.
+type asyncSleepDB struct {
+	sleepDB
+	inFlight, maxInFlight *int64
+}
+
+func (d asyncSleepDB) start(done func(error)) {
+	n := atomic.AddInt64(d.inFlight, 1)
+	for max := atomic.LoadInt64(d.maxInFlight); n > max; max = atomic.LoadInt64(d.maxInFlight) {
+		if atomic.CompareAndSwapInt64(d.maxInFlight, max, n) {
+			break
+		}
+	}
+	time.AfterFunc(d.delay, func() {
+		atomic.AddInt64(d.inFlight, -1)
+		done(nil)
+	})
+}
+
+func (d asyncSleepDB) ReadAsync(_ context.Context, _ string, _ string, _ []string, done func(map[string][]byte, error)) {
+	d.start(func(err error) { done(nil, err) })
+}
+
+func (d asyncSleepDB) UpdateAsync(_ context.Context, _ string, _ string, _ map[string][]byte, done func(error)) {
+	d.start(done)
+}
+
+func (d asyncSleepDB) InsertAsync(_ context.Context, _ string, _ string, _ map[string][]byte, done func(error)) {
+	d.start(done)
+}
+
+func (d asyncSleepDB) DeleteAsync(_ context.Context, _ string, _ string, done func(error)) {
+	d.start(done)
+}
+
+func TestRunAsyncDB(t *testing.T) {
+	p := newTestProperties(
+		prop.ThreadCount, "2",
+		prop.OperationCount, "41",
+		prop.AsyncDepth, "4",
+	)
+	workload := &sleepWorkload{}
+	var inFlight, maxInFlight int64
+	db := asyncSleepDB{sleepDB: sleepDB{delay: 20 * time.Millisecond}, inFlight: &inFlight, maxInFlight: &maxInFlight}
+	c := NewClient(p, workload, DbWrapper{DB: db})
+
+	start := time.Now()
+	c.Run(context.Background())
+	elapsed := time.Since(start)
+
+	// 2 workers do 21 and 20 operations of 20ms, 4 at a time.
+	if elapsed < 100*time.Millisecond || elapsed > 300*time.Millisecond {
+		t.Errorf("run took %s, want about 120ms", elapsed)
+	}
+	if maxInFlight != 8 {
+		t.Errorf("got up to %d operations in flight, want 4 per worker", maxInFlight)
+	}
+	if n := atomic.LoadInt64(&workload.cleanups); n != 2 {
+		t.Errorf("workload CleanupThread called %d times, want one per worker", n)
+	}
+	// the run returns once the operations in flight are measured
+	read := measurement.Histograms()["READ"]
+	if read == nil || read.TotalCount() != 41 || read.Min() < 20000 {
+		t.Fatalf("got READ %v, want 41 operations of 20ms", read)
+	}
+}
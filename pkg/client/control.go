@@ -0,0 +1,130 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+var (
+	activeMu     sync.Mutex
+	activeClient *Client
+	// lastClient is the client of the latest run, its results stay exported
+	// by the metrics endpoint once the run is over.
+	lastClient *Client
+)
+
+// setActiveClient sets the client the control API acts on, nil if no run is
+// in progress.
+func setActiveClient(c *Client) {
+	activeMu.Lock()
+	activeClient = c
+	if c != nil {
+		lastClient = c
+	}
+	activeMu.Unlock()
+}
+
+func getLastClient() *Client {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return lastClient
+}
+
+func getActiveClient() *Client {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	return activeClient
+}
+
+// ControlStatus is the state of the running benchmark returned by the control API.
+type ControlStatus struct {
+	Running        bool    `json:"running"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	OpsDone        int64   `json:"ops_done"`
+	// Target is the current total target in ops/sec, 0 if not throttled.
+	Target      float64 `json:"target"`
+	ThreadCount int     `json:"thread_count"`
+}
+
+// Status returns the current state of the run.
+func (c *Client) Status() ControlStatus {
+	return ControlStatus{
+		Running:        c.stopCtx.Err() == nil,
+		ElapsedSeconds: c.Elapsed().Seconds(),
+		OpsDone:        c.OpsDone(),
+		Target:         c.Target(),
+		ThreadCount:    c.ThreadCount(),
+	}
+}
+
+// ControlHandler returns the handler of the control API, to be served under
+// /control/ on the debug server:
+//
+//	GET  /control/status          the run status as JSON
+//	POST /control/target?ops=N    set a constant target, 0 removes the throttling
+//	POST /control/threads?count=N start or stop workers until N are running
+//	POST /control/stop            stop the run, the results are still reported
+func ControlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/control/status", controlFunc(http.MethodGet, func(c *Client, r *http.Request) error {
+		return nil
+	}))
+	mux.HandleFunc("/control/target", controlFunc(http.MethodPost, func(c *Client, r *http.Request) error {
+		target, err := strconv.ParseFloat(r.FormValue("ops"), 64)
+		if err != nil {
+			return fmt.Errorf("invalid ops %q", r.FormValue("ops"))
+		}
+		c.SetTarget(target)
+		return nil
+	}))
+	mux.HandleFunc("/control/threads", controlFunc(http.MethodPost, func(c *Client, r *http.Request) error {
+		n, err := strconv.Atoi(r.FormValue("count"))
+		if err != nil {
+			return fmt.Errorf("invalid count %q", r.FormValue("count"))
+		}
+		return c.SetThreadCount(n)
+	}))
+	mux.HandleFunc("/control/stop", controlFunc(http.MethodPost, func(c *Client, r *http.Request) error {
+		c.Stop()
+		return nil
+	}))
+	return mux
+}
+
+// controlFunc runs fn on the active client and replies with the run status.
+func controlFunc(method string, fn func(c *Client, r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			http.Error(w, fmt.Sprintf("%s only", method), http.StatusMethodNotAllowed)
+			return
+		}
+		c := getActiveClient()
+		if c == nil {
+			http.Error(w, "no run in progress", http.StatusConflict)
+			return
+		}
+		if err := fn(c, r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Status())
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+func controlRequest(t *testing.T, h http.Handler, method string, url string) (int, ControlStatus) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, url, nil))
+
+	var status ControlStatus
+	if rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+			t.Fatalf("%s %s: invalid status %q: %v", method, url, rec.Body.String(), err)
+		}
+	}
+	return rec.Code, status
+}
+
+func TestControlHandler(t *testing.T) {
+	h := ControlHandler()
+	if code, _ := controlRequest(t, h, http.MethodGet, "/control/status"); code != http.StatusConflict {
+		t.Fatalf("status without a run returned %d, want %d", code, http.StatusConflict)
+	}
+
+	p := newTestProperties(prop.ThreadCount, "2", prop.Target, "200")
+	workload := &sleepWorkload{}
+	c := NewClient(p, workload, DbWrapper{DB: sleepDB{delay: time.Millisecond}})
+	done := make(chan struct{})
+	go func() {
+		c.Run(context.Background())
+		close(done)
+	}()
+
+	for getActiveClient() == nil {
+		time.Sleep(time.Millisecond)
+	}
+
+	tests := []struct {
+		method  string
+		url     string
+		code    int
+		target  float64
+		threads int
+	}{
+		{http.MethodGet, "/control/status", http.StatusOK, 200, 2},
+		{http.MethodGet, "/control/target?ops=10", http.StatusMethodNotAllowed, 0, 0},
+		{http.MethodPost, "/control/target?ops=abc", http.StatusBadRequest, 0, 0},
+		{http.MethodPost, "/control/target?ops=500", http.StatusOK, 500, 2},
+		{http.MethodPost, "/control/threads?count=5", http.StatusOK, 500, 5},
+		{http.MethodPost, "/control/threads?count=1", http.StatusOK, 500, 1},
+		{http.MethodPost, "/control/threads?count=0", http.StatusBadRequest, 0, 0},
+		{http.MethodPost, "/control/target?ops=0", http.StatusOK, 0, 1},
+	}
+	for _, test := range tests {
+		code, status := controlRequest(t, h, test.method, test.url)
+		if code != test.code {
+			t.Errorf("%s %s returned %d, want %d", test.method, test.url, code, test.code)
+			continue
+		}
+		if code != http.StatusOK {
+			continue
+		}
+		if !status.Running || status.Target != test.target || status.ThreadCount != test.threads {
+			t.Errorf("%s %s returned %+v, want target %v and %d threads", test.method, test.url, status, test.target, test.threads)
+		}
+	}
+
+	if code, _ := controlRequest(t, h, http.MethodPost, "/control/stop"); code != http.StatusOK {
+		t.Fatalf("stop returned %d", code)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run did not stop")
+	}
+	// Five workers were started in total.
+	if n := atomic.LoadInt64(&workload.cleanups); n != 5 {
+		t.Errorf("CleanupThread called %d times, want 5", n)
+	}
+	if c.OpsDone() == 0 {
+		t.Errorf("no operation was done")
+	}
+}
@@ -25,17 +25,133 @@ import (
 // DbWrapper stores the pointer to a implementation of ycsb.DB.
 type DbWrapper struct {
 	DB ycsb.DB
+	// Retry is the policy for failed operations, nil never retries.
+	Retry *RetryPolicy
+	// Timeouts are the deadlines of the operation attempts, nil has none.
+	Timeouts Timeouts
 }
 
-func measure(start time.Time, op string, err error) {
+func measure(ctx context.Context, start time.Time, op string, err error) {
 	lan := time.Now().Sub(start)
 	if err != nil {
-		measurement.Measure(fmt.Sprintf("%s_ERROR", op), start, lan)
+		measurement.MeasureContext(ctx, fmt.Sprintf("%s_ERROR", op), start, lan)
 		return
 	}
 
-	measurement.Measure(op, start, lan)
-	measurement.Measure("TOTAL", start, lan)
+	measurement.MeasureOperation(ctx, op, start, lan)
+	measurement.MeasureOperation(ctx, "TOTAL", start, lan)
+}
+
+// do runs the operation fn, retrying it as allowed by the retry policy. The
+// operation latency covers all attempts and backoffs, every retried attempt is
+// measured as <OP>_RETRY and, when retrying is enabled, the last attempt as
+// <OP>_FINAL_ATTEMPT. Every attempt gets the operation timeout as deadline,
+// an operation whose last attempt timed out is measured as <OP>_TIMEOUT.
+// Failures are also counted by error class.
+func (db DbWrapper) do(ctx context.Context, op string, fn func(ctx context.Context) error) (err error) {
+	start := time.Now()
+	maxAttempts := 1
+	if db.Retry.Enabled() {
+		maxAttempts = db.Retry.MaxAttempts
+	}
+	timeout := db.Timeouts.get(op)
+
+	var attemptStart time.Time
+	var timedOut bool
+	for attempt := 1; ; attempt++ {
+		attemptStart = time.Now()
+		timedOut, err = db.attempt(ctx, timeout, fn)
+		// A timed out attempt is worth retrying whatever error the DB returned.
+		if err == nil || attempt >= maxAttempts || !(timedOut || isRetryable(db.DB, err)) {
+			break
+		}
+		measurement.MeasureContext(ctx, fmt.Sprintf("%s_RETRY", op), attemptStart, time.Now().Sub(attemptStart))
+
+		select {
+		case <-ctx.Done():
+			measure(ctx, start, op, err)
+			measurement.MeasureError(op, classifyError(db.DB, err), err)
+			return err
+		case <-time.After(db.Retry.Backoff(attempt + 1)):
+		}
+	}
+
+	db.measureDone(ctx, op, start, attemptStart, maxAttempts, timedOut, err)
+	return err
+}
+
+// doAsync starts the operation fn like do, fn calls done once the attempt
+// completed. finished is called once the operation is measured.
+func (db DbWrapper) doAsync(ctx context.Context, op string, fn func(ctx context.Context, done func(error)), finished func()) {
+	start := time.Now()
+	maxAttempts := 1
+	if db.Retry.Enabled() {
+		maxAttempts = db.Retry.MaxAttempts
+	}
+	timeout := db.Timeouts.get(op)
+
+	var attempt func(n int)
+	attempt = func(n int) {
+		attemptStart := time.Now()
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if timeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+		}
+		fn(attemptCtx, func(err error) {
+			timedOut := err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+			cancel()
+			if err == nil || n >= maxAttempts || !(timedOut || isRetryable(db.DB, err)) {
+				db.measureDone(ctx, op, start, attemptStart, maxAttempts, timedOut, err)
+				finished()
+				return
+			}
+			measurement.MeasureContext(ctx, fmt.Sprintf("%s_RETRY", op), attemptStart, time.Now().Sub(attemptStart))
+
+			time.AfterFunc(db.Retry.Backoff(n+1), func() {
+				if ctx.Err() != nil {
+					measure(ctx, start, op, err)
+					measurement.MeasureError(op, classifyError(db.DB, err), err)
+					finished()
+					return
+				}
+				attempt(n + 1)
+			})
+		})
+	}
+	attempt(1)
+}
+
+// measureDone measures an operation started at start whose last attempt
+// started at attemptStart and failed with err, if not nil.
+func (db DbWrapper) measureDone(ctx context.Context, op string, start time.Time, attemptStart time.Time, maxAttempts int, timedOut bool, err error) {
+	if err == nil && maxAttempts > 1 {
+		measurement.MeasureContext(ctx, fmt.Sprintf("%s_FINAL_ATTEMPT", op), attemptStart, time.Now().Sub(attemptStart))
+	}
+	if timedOut {
+		measurement.MeasureContext(ctx, fmt.Sprintf("%s_TIMEOUT", op), start, time.Now().Sub(start))
+		measurement.MeasureError(op, ErrorClassTimeout, err)
+		return
+	}
+	measure(ctx, start, op, err)
+	if err != nil {
+		measurement.MeasureError(op, classifyError(db.DB, err), err)
+	}
+}
+
+// attempt runs fn once with the timeout, if any, and tells whether it failed
+// because the timeout elapsed.
+func (db DbWrapper) attempt(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) (bool, error) {
+	if timeout <= 0 {
+		return false, fn(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := fn(attemptCtx)
+	if err != nil && attemptCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+		return true, err
+	}
+	return false, err
 }
 
 func (db DbWrapper) Close() error {
@@ -50,23 +166,33 @@ func (db DbWrapper) CleanupThread(ctx context.Context) {
 	db.DB.CleanupThread(ctx)
 }
 
-func (db DbWrapper) Read(ctx context.Context, table string, key string, fields []string) (_ map[string][]byte, err error) {
-	start := time.Now()
-	defer func() {
-		measure(start, "READ", err)
-	}()
+// sharedThreadDB returns db, or the DB it wraps, if it implements
+// ycsb.SharedThreadDB.
+func sharedThreadDB(db ycsb.DB) (ycsb.SharedThreadDB, bool) {
+	if w, ok := db.(DbWrapper); ok {
+		db = w.DB
+	}
+	s, ok := db.(ycsb.SharedThreadDB)
+	return s, ok
+}
 
-	return db.DB.Read(ctx, table, key, fields)
+func (db DbWrapper) Read(ctx context.Context, table string, key string, fields []string) (row map[string][]byte, err error) {
+	err = db.do(ctx, "READ", func(ctx context.Context) (err error) {
+		row, err = db.DB.Read(ctx, table, key, fields)
+		return err
+	})
+	return row, err
 }
 
 func (db DbWrapper) BatchRead(ctx context.Context, table string, keys []string, fields []string) (_ []map[string][]byte, err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_READ", err)
-		}()
-		return batchDB.BatchRead(ctx, table, keys, fields)
+		var rows []map[string][]byte
+		err = db.do(ctx, "BATCH_READ", func(ctx context.Context) (err error) {
+			rows, err = batchDB.BatchRead(ctx, table, keys, fields)
+			return err
+		})
+		return rows, err
 	}
 	for _, key := range keys {
 		_, err := db.DB.Read(ctx, table, key, fields)
@@ -77,32 +203,26 @@ func (db DbWrapper) BatchRead(ctx context.Context, table string, keys []string,
 	return nil, nil
 }
 
-func (db DbWrapper) Scan(ctx context.Context, table string, startKey string, count int, fields []string) (_ []map[string][]byte, err error) {
-	start := time.Now()
-	defer func() {
-		measure(start, "SCAN", err)
-	}()
-
-	return db.DB.Scan(ctx, table, startKey, count, fields)
+func (db DbWrapper) Scan(ctx context.Context, table string, startKey string, count int, fields []string) (rows []map[string][]byte, err error) {
+	err = db.do(ctx, "SCAN", func(ctx context.Context) (err error) {
+		rows, err = db.DB.Scan(ctx, table, startKey, count, fields)
+		return err
+	})
+	return rows, err
 }
 
 func (db DbWrapper) Update(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
-	start := time.Now()
-	defer func() {
-		measure(start, "UPDATE", err)
-	}()
-
-	return db.DB.Update(ctx, table, key, values)
+	return db.do(ctx, "UPDATE", func(ctx context.Context) error {
+		return db.DB.Update(ctx, table, key, values)
+	})
 }
 
 func (db DbWrapper) BatchUpdate(ctx context.Context, table string, keys []string, values []map[string][]byte) (err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_UPDATE", err)
-		}()
-		return batchDB.BatchUpdate(ctx, table, keys, values)
+		return db.do(ctx, "BATCH_UPDATE", func(ctx context.Context) error {
+			return batchDB.BatchUpdate(ctx, table, keys, values)
+		})
 	}
 	for i := range keys {
 		err := db.DB.Update(ctx, table, keys[i], values[i])
@@ -114,22 +234,17 @@ func (db DbWrapper) BatchUpdate(ctx context.Context, table string, keys []string
 }
 
 func (db DbWrapper) Insert(ctx context.Context, table string, key string, values map[string][]byte) (err error) {
-	start := time.Now()
-	defer func() {
-		measure(start, "INSERT", err)
-	}()
-
-	return db.DB.Insert(ctx, table, key, values)
+	return db.do(ctx, "INSERT", func(ctx context.Context) error {
+		return db.DB.Insert(ctx, table, key, values)
+	})
 }
 
 func (db DbWrapper) BatchInsert(ctx context.Context, table string, keys []string, values []map[string][]byte) (err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_INSERT", err)
-		}()
-		return batchDB.BatchInsert(ctx, table, keys, values)
+		return db.do(ctx, "BATCH_INSERT", func(ctx context.Context) error {
+			return batchDB.BatchInsert(ctx, table, keys, values)
+		})
 	}
 	for i := range keys {
 		err := db.DB.Insert(ctx, table, keys[i], values[i])
@@ -141,22 +256,17 @@ func (db DbWrapper) BatchInsert(ctx context.Context, table string, keys []string
 }
 
 func (db DbWrapper) Delete(ctx context.Context, table string, key string) (err error) {
-	start := time.Now()
-	defer func() {
-		measure(start, "DELETE", err)
-	}()
-
-	return db.DB.Delete(ctx, table, key)
+	return db.do(ctx, "DELETE", func(ctx context.Context) error {
+		return db.DB.Delete(ctx, table, key)
+	})
 }
 
 func (db DbWrapper) BatchDelete(ctx context.Context, table string, keys []string) (err error) {
 	batchDB, ok := db.DB.(ycsb.BatchDB)
 	if ok {
-		start := time.Now()
-		defer func() {
-			measure(start, "BATCH_DELETE", err)
-		}()
-		return batchDB.BatchDelete(ctx, table, keys)
+		return db.do(ctx, "BATCH_DELETE", func(ctx context.Context) error {
+			return batchDB.BatchDelete(ctx, table, keys)
+		})
 	}
 	for _, key := range keys {
 		err := db.DB.Delete(ctx, table, key)
@@ -0,0 +1,137 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// BenchmarkNameEnv is the environment variable naming the benchmark in the
+// metrics, it takes precedence over the label property.
+const BenchmarkNameEnv = "BENCHMARK_NAME"
+
+var metricQuantiles = []float64{0.5, 0.9, 0.95, 0.99, 0.999}
+
+// MetricsHandler returns the handler exporting the metrics of the latest run
+// in the Prometheus text format, to be served as /metrics on the debug server.
+// The operation metrics need the histogram measurement type.
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		if c := getLastClient(); c != nil {
+			benchmark := os.Getenv(BenchmarkNameEnv)
+			if benchmark == "" {
+				benchmark = c.p.GetString(prop.Label, "")
+			}
+			labels := fmt.Sprintf("benchmark=%s,db=%s", quoteLabel(benchmark), quoteLabel(c.p.GetString(prop.DB, "")))
+			writeMetrics(&buf, labels, c.Status(), measurement.Histograms())
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(buf.Bytes())
+	})
+}
+
+func quoteLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// writeMetrics writes the run status and the operation histograms, labels are
+// added to every sample. Failed and timed out operations are counted by
+// ycsb_operation_errors_total and retried attempts by
+// ycsb_operation_retries_total, under the operation. The operation latencies
+// are labeled with their kind: op, intended, retry or final_attempt.
+func writeMetrics(w io.Writer, labels string, status ControlStatus, hists map[string]*hdrhistogram.Histogram) {
+	running := 0
+	if status.Running {
+		running = 1
+	}
+	gauges := []struct {
+		name  string
+		help  string
+		value float64
+	}{
+		{"ycsb_running", "Whether the run is in progress.", float64(running)},
+		{"ycsb_elapsed_seconds", "Time since the run started.", status.ElapsedSeconds},
+		{"ycsb_target_ops_per_second", "Current total target, 0 if not throttled.", status.Target},
+		{"ycsb_threads", "Running worker threads.", float64(status.ThreadCount)},
+	}
+	for _, g := range gauges {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s{%s} %s\n", g.name, g.help, g.name, g.name, labels, formatFloat(g.value))
+	}
+
+	ops := make([]string, 0, len(hists))
+	for op := range hists {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	// the attempts of an operation are not operations of their own
+	fmt.Fprintf(w, "# HELP ycsb_operations_total Operations completed, by operation and kind of latency (op or intended).\n# TYPE ycsb_operations_total counter\n")
+	for _, name := range ops {
+		if op, kind := measurement.SplitOperation(name); kind == measurement.KindOp || kind == measurement.KindIntended {
+			fmt.Fprintf(w, "ycsb_operations_total{%s,operation=%s,kind=%q} %d\n", labels, quoteLabel(op), kind, hists[name].TotalCount())
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP ycsb_operation_retries_total Attempts that were retried, by operation.\n# TYPE ycsb_operation_retries_total counter\n")
+	for _, name := range ops {
+		if op, kind := measurement.SplitOperation(name); kind == measurement.KindRetry {
+			fmt.Fprintf(w, "ycsb_operation_retries_total{%s,operation=%s} %d\n", labels, quoteLabel(op), hists[name].TotalCount())
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP ycsb_operation_errors_total Failed operations, by operation and kind (error or timeout).\n# TYPE ycsb_operation_errors_total counter\n")
+	for _, name := range ops {
+		if op, kind := measurement.SplitOperation(name); isFailure(kind) {
+			fmt.Fprintf(w, "ycsb_operation_errors_total{%s,operation=%s,kind=%q} %d\n", labels, quoteLabel(op), kind, hists[name].TotalCount())
+		}
+	}
+
+	fmt.Fprintf(w, "# HELP ycsb_operation_latency_microseconds Latency of the completed operations since the run started, by kind of latency.\n# TYPE ycsb_operation_latency_microseconds summary\n")
+	for _, name := range ops {
+		op, kind := measurement.SplitOperation(name)
+		if isFailure(kind) {
+			continue
+		}
+		hist := hists[name]
+		opLabels := fmt.Sprintf("%s,operation=%s,kind=%q", labels, quoteLabel(op), kind)
+		for _, q := range metricQuantiles {
+			fmt.Fprintf(w, "ycsb_operation_latency_microseconds{%s,quantile=\"%s\"} %d\n", opLabels, formatFloat(q), hist.ValueAtQuantile(q*100))
+		}
+		sum := hist.Mean() * float64(hist.TotalCount())
+		fmt.Fprintf(w, "ycsb_operation_latency_microseconds_sum{%s} %s\n", opLabels, formatFloat(sum))
+		fmt.Fprintf(w, "ycsb_operation_latency_microseconds_count{%s} %d\n", opLabels, hist.TotalCount())
+	}
+}
+
+func isFailure(kind string) bool {
+	return kind == measurement.KindError || kind == measurement.KindTimeout
+}
@@ -0,0 +1,78 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	hists := make(map[string]*hdrhistogram.Histogram)
+	for op, latency := range map[string]int64{
+		"READ":               1000,
+		"READ_ERROR":         50,
+		"READ_RETRY":         400,
+		"READ_FINAL_ATTEMPT": 500,
+		"INTENDED_READ":      1500,
+		"UPDATE_TIMEOUT":     2000,
+	} {
+		hists[op] = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+		hists[op].RecordValues(latency, 4)
+	}
+	status := ControlStatus{Running: true, ElapsedSeconds: 1.5, Target: 200, ThreadCount: 8}
+
+	var buf bytes.Buffer
+	writeMetrics(&buf, `benchmark="a\"b",db="basic"`, status, hists)
+	out := buf.String()
+
+	for _, want := range []string{
+		`ycsb_running{benchmark="a\"b",db="basic"} 1`,
+		`ycsb_target_ops_per_second{benchmark="a\"b",db="basic"} 200`,
+		`ycsb_threads{benchmark="a\"b",db="basic"} 8`,
+		`ycsb_operations_total{benchmark="a\"b",db="basic",operation="READ",kind="op"} 4`,
+		`ycsb_operations_total{benchmark="a\"b",db="basic",operation="READ",kind="intended"} 4`,
+		`ycsb_operation_retries_total{benchmark="a\"b",db="basic",operation="READ"} 4`,
+		`ycsb_operation_errors_total{benchmark="a\"b",db="basic",operation="READ",kind="error"} 4`,
+		`ycsb_operation_errors_total{benchmark="a\"b",db="basic",operation="UPDATE",kind="timeout"} 4`,
+		`ycsb_operation_latency_microseconds{benchmark="a\"b",db="basic",operation="READ",kind="op",quantile="0.99"} 1000`,
+		`ycsb_operation_latency_microseconds{benchmark="a\"b",db="basic",operation="READ",kind="final_attempt",quantile="0.99"} 500`,
+		`ycsb_operation_latency_microseconds{benchmark="a\"b",db="basic",operation="READ",kind="intended",quantile="0.99"} 1500`,
+		`ycsb_operation_latency_microseconds_count{benchmark="a\"b",db="basic",operation="READ",kind="op"} 4`,
+		"# TYPE ycsb_operation_latency_microseconds summary",
+	} {
+		if !strings.Contains(out, want+"\n") {
+			t.Errorf("missing %s in\n%s", want, out)
+		}
+	}
+	for _, kind := range []string{"retry", "final_attempt"} {
+		if strings.Contains(out, `ycsb_operations_total{benchmark="a\"b",db="basic",operation="READ",kind="`+kind+`"}`) {
+			t.Errorf("%s attempts should not be counted as operations\n%s", kind, out)
+		}
+	}
+	for _, op := range []string{"READ_ERROR", "READ_RETRY", "READ_FINAL_ATTEMPT", "INTENDED_READ"} {
+		if strings.Contains(out, `operation="`+op+`"`) {
+			t.Errorf("%s should be counted under READ\n%s", op, out)
+		}
+	}
+}
+
+func TestQuoteLabel(t *testing.T) {
+	if got, want := quoteLabel("a\\b\"c\nd"), `"a\\b\"c\nd"`; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// RetryPolicy decides how many times a failed operation is issued again and
+// how long to wait in between.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, 1 disables retrying.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter spreads every backoff randomly by +/- this fraction.
+	Jitter float64
+}
+
+func getRetryDuration(p *properties.Properties, key string, def string) (time.Duration, error) {
+	v := p.GetString(key, def)
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", key, v, err)
+	}
+	return d, nil
+}
+
+// NewRetryPolicy creates the retry policy configured by the retry.* properties.
+func NewRetryPolicy(p *properties.Properties) (*RetryPolicy, error) {
+	r := &RetryPolicy{
+		MaxAttempts: p.GetInt(prop.RetryMaxAttempts, prop.RetryMaxAttemptsDefault),
+		Multiplier:  p.GetFloat64(prop.RetryBackoffMultiplier, prop.RetryBackoffMultiplierDefault),
+		Jitter:      p.GetFloat64(prop.RetryBackoffJitter, prop.RetryBackoffJitterDefault),
+	}
+	var err error
+	if r.InitialBackoff, err = getRetryDuration(p, prop.RetryBackoffInitial, prop.RetryBackoffInitialDefault); err != nil {
+		return nil, err
+	}
+	if r.MaxBackoff, err = getRetryDuration(p, prop.RetryBackoffMax, prop.RetryBackoffMaxDefault); err != nil {
+		return nil, err
+	}
+
+	if r.MaxAttempts < 1 {
+		return nil, fmt.Errorf("%s must be at least 1, got %d", prop.RetryMaxAttempts, r.MaxAttempts)
+	}
+	if r.Multiplier < 1 {
+		return nil, fmt.Errorf("%s must be at least 1, got %v", prop.RetryBackoffMultiplier, r.Multiplier)
+	}
+	if r.Jitter < 0 || r.Jitter > 1 {
+		return nil, fmt.Errorf("%s must be within [0, 1], got %v", prop.RetryBackoffJitter, r.Jitter)
+	}
+	return r, nil
+}
+
+// Enabled returns true if failed operations may be retried.
+func (r *RetryPolicy) Enabled() bool {
+	return r != nil && r.MaxAttempts > 1
+}
+
+// Backoff returns the wait before the given attempt, the first retry being attempt 2.
+func (r *RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(attempt-2))
+	if max := float64(r.MaxBackoff); r.MaxBackoff > 0 && d > max {
+		d = max
+	}
+	d *= 1 - r.Jitter + 2*r.Jitter*rand.Float64()
+	return time.Duration(d)
+}
+
+// Error classes of the failures the DB doesn't classify.
+const (
+	ErrorClassTimeout  = "timeout"
+	ErrorClassCanceled = "canceled"
+	ErrorClassOther    = "other"
+)
+
+// classifyError asks the DB for the class of err if it can, the cancellation
+// and timeout of the operation context are classified first.
+func classifyError(db ycsb.DB, err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTimeout
+	}
+	if errors.Is(err, context.Canceled) {
+		return ErrorClassCanceled
+	}
+	if classifier, ok := db.(ycsb.ErrorClassifier); ok {
+		if class := classifier.ClassifyError(err); class != "" {
+			return class
+		}
+	}
+	return ErrorClassOther
+}
+
+// isRetryable asks the DB to classify err if it can. Otherwise every error is
+// retried but the cancellation of the operation itself.
+func isRetryable(db ycsb.DB, err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if retryableDB, ok := db.(ycsb.RetryableDB); ok {
+		return retryableDB.IsRetryable(err)
+	}
+	return true
+}
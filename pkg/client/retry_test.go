@@ -0,0 +1,183 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+var (
+	errConflict = errors.New("conflict")
+	errFatal    = errors.New("fatal")
+)
+
+// flakyDB fails the first failures reads with err.
+type flakyDB struct {
+	sleepDB
+	failures int
+	err      error
+}
+
+func (d *flakyDB) Read(ctx context.Context, table string, key string, fields []string) (map[string][]byte, error) {
+	if d.failures > 0 {
+		d.failures--
+		return nil, d.err
+	}
+	return d.sleepDB.Read(ctx, table, key, fields)
+}
+
+// syncAsyncDB completes the async operations of DB before returning, as
+// allowed to AsyncDBs.
+type syncAsyncDB struct {
+	ycsb.DB
+}
+
+func (d syncAsyncDB) ReadAsync(ctx context.Context, table string, key string, fields []string, done func(map[string][]byte, error)) {
+	done(d.Read(ctx, table, key, fields))
+}
+
+func (d syncAsyncDB) UpdateAsync(ctx context.Context, table string, key string, values map[string][]byte, done func(error)) {
+	done(d.Update(ctx, table, key, values))
+}
+
+func (d syncAsyncDB) InsertAsync(ctx context.Context, table string, key string, values map[string][]byte, done func(error)) {
+	done(d.Insert(ctx, table, key, values))
+}
+
+func (d syncAsyncDB) DeleteAsync(ctx context.Context, table string, key string, done func(error)) {
+	done(d.Delete(ctx, table, key))
+}
+
+func (d *flakyDB) IsRetryable(err error) bool {
+	return err == errConflict
+}
+
+func (d *flakyDB) ClassifyError(err error) string {
+	if err == errConflict {
+		return "conflict"
+	}
+	return ""
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r := &RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2,
+	}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{2, 10 * time.Millisecond},
+		{3, 20 * time.Millisecond},
+		{4, 40 * time.Millisecond},
+		{5, 50 * time.Millisecond},
+		{9, 50 * time.Millisecond},
+	}
+	for _, test := range tests {
+		if got := r.Backoff(test.attempt); got != test.want {
+			t.Errorf("attempt %d: got backoff %s, want %s", test.attempt, got, test.want)
+		}
+	}
+
+	r.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := r.Backoff(3); got < 10*time.Millisecond || got >= 30*time.Millisecond {
+			t.Fatalf("got backoff %s with jitter, want within [10ms, 30ms)", got)
+		}
+	}
+}
+
+func TestDbWrapperRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    int
+		err         error
+		wantErr     bool
+		wantRetries int64
+	}{
+		{"disabled", 1, 1, errConflict, true, 0},
+		{"recovers", 3, 2, errConflict, false, 2},
+		{"exhausted", 3, 5, errConflict, true, 2},
+		{"not retryable", 3, 1, errFatal, true, 0},
+	}
+	for _, test := range tests {
+		for _, async := range []bool{false, true} {
+			newTestProperties()
+			policy := &RetryPolicy{MaxAttempts: test.maxAttempts, InitialBackoff: time.Millisecond, Multiplier: 2}
+			db := DbWrapper{DB: &flakyDB{failures: test.failures, err: test.err}, Retry: policy}
+
+			if async {
+				// the async operations only report their errors in the measurements
+				a := newAsyncDB(db, syncAsyncDB{db.DB}, 1)
+				a.Read(context.Background(), "t", "k", nil)
+				a.wait()
+			} else if _, err := db.Read(context.Background(), "t", "k", nil); (err != nil) != test.wantErr {
+				t.Errorf("%s: got err %v, want error %v", test.name, err, test.wantErr)
+			}
+
+			hists := measurement.Histograms()
+			var retries int64
+			if h := hists["READ_RETRY"]; h != nil {
+				retries = h.TotalCount()
+			}
+			if retries != test.wantRetries {
+				t.Errorf("%s async %v: got %d READ_RETRY, want %d", test.name, async, retries, test.wantRetries)
+			}
+			if _, ok := hists["READ_FINAL_ATTEMPT"]; ok != (!test.wantErr && test.maxAttempts > 1) {
+				t.Errorf("%s async %v: unexpected READ_FINAL_ATTEMPT presence %v", test.name, async, ok)
+			}
+			if _, ok := hists["READ_ERROR"]; ok != test.wantErr {
+				t.Errorf("%s async %v: unexpected READ_ERROR presence %v", test.name, async, ok)
+			}
+		}
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("read: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{context.Canceled, ErrorClassCanceled},
+		{errConflict, "conflict"},
+		{errFatal, ErrorClassOther},
+	}
+	for _, test := range tests {
+		if got := classifyError(&flakyDB{}, test.err); got != test.want {
+			t.Errorf("classifyError(%v) = %s, want %s", test.err, got, test.want)
+		}
+	}
+
+	newTestProperties()
+	db := DbWrapper{DB: &flakyDB{failures: 3, err: errConflict}}
+	for i := 0; i < 3; i++ {
+		db.Read(context.Background(), "t", "k", nil)
+	}
+	classes := measurement.ErrorClasses()["READ"]
+	if len(classes) != 1 || classes[0].Class != "conflict" || classes[0].Count != 3 || len(classes[0].Samples) != 1 {
+		t.Errorf("got READ error classes %+v, want 3 conflicts with one sample", classes)
+	}
+}
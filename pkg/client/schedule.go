@@ -0,0 +1,252 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// minScheduleTarget is the lowest total target a schedule can yield, so that
+// workers are never parked forever.
+const minScheduleTarget = 1.0
+
+// scheduleResolution is the largest step used to integrate a schedule.
+const scheduleResolution = 100 * time.Millisecond
+
+// targetSchedule yields the total target in operations per second at a given
+// time since the run started.
+type targetSchedule interface {
+	Target(elapsed time.Duration) float64
+}
+
+type constantSchedule float64
+
+func (s constantSchedule) Target(_ time.Duration) float64 {
+	return float64(s)
+}
+
+// rampSchedule goes linearly from `from` to `to` over duration, then stays at `to`.
+type rampSchedule struct {
+	from     float64
+	to       float64
+	duration time.Duration
+}
+
+func (s rampSchedule) Target(elapsed time.Duration) float64 {
+	if elapsed >= s.duration {
+		return s.to
+	}
+	return s.from + (s.to-s.from)*float64(elapsed)/float64(s.duration)
+}
+
+// stepSchedule starts at start and adds increment every interval, up to max if max > 0.
+type stepSchedule struct {
+	start     float64
+	increment float64
+	interval  time.Duration
+	max       float64
+}
+
+func (s stepSchedule) Target(elapsed time.Duration) float64 {
+	target := s.start + s.increment*float64(elapsed/s.interval)
+	if s.max > 0 && target > s.max {
+		return s.max
+	}
+	return target
+}
+
+// sineSchedule oscillates around base by amplitude with the given period,
+// starting at base and rising first.
+type sineSchedule struct {
+	base      float64
+	amplitude float64
+	period    time.Duration
+}
+
+func (s sineSchedule) Target(elapsed time.Duration) float64 {
+	return s.base + s.amplitude*math.Sin(2*math.Pi*float64(elapsed)/float64(s.period))
+}
+
+type schedulePoint struct {
+	at     time.Duration
+	target float64
+}
+
+// fileSchedule holds the target of each point until the next one. Before the
+// first point the first target is used.
+type fileSchedule []schedulePoint
+
+func (s fileSchedule) Target(elapsed time.Duration) float64 {
+	i := sort.Search(len(s), func(i int) bool { return s[i].at > elapsed })
+	if i == 0 {
+		return s[0].target
+	}
+	return s[i-1].target
+}
+
+func parseScheduleTime(v string) (time.Duration, error) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(v)
+}
+
+// loadFileSchedule reads `time,ops/s` lines. The time is either seconds since
+// the run started or a Go duration. Empty lines, comments starting with '#'
+// and a header line are skipped.
+func loadFileSchedule(path string) (fileSchedule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var s fileSchedule
+	headerSkipped := false
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		seps := strings.Split(line, ",")
+		if len(seps) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected `time,ops/s`, got %q", path, lineNo, line)
+		}
+		at, err := parseScheduleTime(strings.TrimSpace(seps[0]))
+		if err != nil {
+			if len(s) == 0 && !headerSkipped {
+				headerSkipped = true
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: invalid time: %v", path, lineNo, err)
+		}
+		target, err := strconv.ParseFloat(strings.TrimSpace(seps[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid target: %v", path, lineNo, err)
+		}
+		s = append(s, schedulePoint{at: at, target: target})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(s) == 0 {
+		return nil, fmt.Errorf("%s: no schedule points", path)
+	}
+
+	sort.SliceStable(s, func(i, j int) bool { return s[i].at < s[j].at })
+	return s, nil
+}
+
+func getScheduleDuration(p *properties.Properties, key string, def time.Duration) (time.Duration, error) {
+	v, ok := p.Get(key)
+	if !ok {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %v", key, v, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", key, v)
+	}
+	return d, nil
+}
+
+// newTargetSchedule creates the schedule configured by target.schedule. It
+// returns nil if the run is not throttled.
+func newTargetSchedule(p *properties.Properties) (targetSchedule, error) {
+	target := p.GetFloat64(prop.Target, 0)
+	kind := p.GetString(prop.TargetSchedule, prop.TargetScheduleDefault)
+
+	var err error
+	switch kind {
+	case "constant":
+		if target <= 0 {
+			return nil, nil
+		}
+		return constantSchedule(target), nil
+	case "ramp":
+		s := rampSchedule{
+			from: p.GetFloat64(prop.TargetRampFrom, 0),
+			to:   p.GetFloat64(prop.TargetRampTo, target),
+		}
+		if s.duration, err = getScheduleDuration(p, prop.TargetRampDuration, 0); err != nil {
+			return nil, err
+		}
+		if s.duration == 0 {
+			return nil, fmt.Errorf("%s is required for the ramp schedule", prop.TargetRampDuration)
+		}
+		return s, nil
+	case "step":
+		s := stepSchedule{
+			start:     p.GetFloat64(prop.TargetStepStart, target),
+			increment: p.GetFloat64(prop.TargetStepIncrement, 0),
+			max:       p.GetFloat64(prop.TargetStepMax, 0),
+		}
+		if s.interval, err = getScheduleDuration(p, prop.TargetStepInterval, time.Minute); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "sine":
+		s := sineSchedule{
+			base:      p.GetFloat64(prop.TargetSineBase, target),
+			amplitude: p.GetFloat64(prop.TargetSineAmplitude, 0),
+		}
+		if s.period, err = getScheduleDuration(p, prop.TargetSinePeriod, 24*time.Hour); err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "file":
+		path := p.GetString(prop.TargetScheduleFile, "")
+		if path == "" {
+			return nil, fmt.Errorf("%s is required for the file schedule", prop.TargetScheduleFile)
+		}
+		return loadFileSchedule(path)
+	default:
+		return nil, fmt.Errorf("unknown %s %q", prop.TargetSchedule, kind)
+	}
+}
+
+// scheduleTarget returns the total target of s at elapsed, never lower than minScheduleTarget.
+func scheduleTarget(s targetSchedule, elapsed time.Duration) float64 {
+	return math.Max(s.Target(elapsed), minScheduleTarget)
+}
+
+// scheduleAdvance returns the time after from at which a worker, owning one
+// threadCount-th of the schedule, is due to start its next operation after
+// doing ops operations.
+func scheduleAdvance(s targetSchedule, threadCount int, from time.Duration, ops float64) time.Duration {
+	at := from
+	for {
+		rate := scheduleTarget(s, at) / float64(threadCount)
+		need := time.Duration(ops / rate * float64(time.Second))
+		if need <= scheduleResolution {
+			return at + need
+		}
+		ops -= rate * scheduleResolution.Seconds()
+		at += scheduleResolution
+	}
+}
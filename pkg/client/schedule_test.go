@@ -0,0 +1,90 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+func TestTargetSchedule(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schedule.csv")
+	content := "time,ops\n0,100\n# night\n1h,10\n7200,50\n"
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		props   map[string]string
+		elapsed time.Duration
+		want    float64
+	}{
+		{"constant", map[string]string{prop.Target: "500"}, time.Hour, 500},
+		{"ramp start", map[string]string{prop.TargetSchedule: "ramp", prop.TargetRampFrom: "100", prop.TargetRampTo: "1100", prop.TargetRampDuration: "10s"}, 0, 100},
+		{"ramp middle", map[string]string{prop.TargetSchedule: "ramp", prop.TargetRampFrom: "100", prop.TargetRampTo: "1100", prop.TargetRampDuration: "10s"}, 5 * time.Second, 600},
+		{"ramp end", map[string]string{prop.TargetSchedule: "ramp", prop.TargetRampFrom: "100", prop.TargetRampTo: "1100", prop.TargetRampDuration: "10s"}, time.Minute, 1100},
+		{"step", map[string]string{prop.TargetSchedule: "step", prop.TargetStepStart: "1000", prop.TargetStepIncrement: "500", prop.TargetStepInterval: "30s"}, 65 * time.Second, 2000},
+		{"step max", map[string]string{prop.TargetSchedule: "step", prop.TargetStepStart: "1000", prop.TargetStepIncrement: "500", prop.TargetStepInterval: "30s", prop.TargetStepMax: "1800"}, 65 * time.Second, 1800},
+		{"sine peak", map[string]string{prop.TargetSchedule: "sine", prop.TargetSineBase: "1000", prop.TargetSineAmplitude: "400", prop.TargetSinePeriod: "4m"}, time.Minute, 1400},
+		{"sine trough", map[string]string{prop.TargetSchedule: "sine", prop.TargetSineBase: "1000", prop.TargetSineAmplitude: "400", prop.TargetSinePeriod: "4m"}, 3 * time.Minute, 600},
+		{"file first", map[string]string{prop.TargetSchedule: "file", prop.TargetScheduleFile: file}, 30 * time.Minute, 100},
+		{"file second", map[string]string{prop.TargetSchedule: "file", prop.TargetScheduleFile: file}, 90 * time.Minute, 10},
+		{"file last", map[string]string{prop.TargetSchedule: "file", prop.TargetScheduleFile: file}, 3 * time.Hour, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := newTargetSchedule(properties.LoadMap(tt.props))
+			if err != nil {
+				t.Fatalf("newTargetSchedule: %v", err)
+			}
+			if got := s.Target(tt.elapsed); math.Abs(got-tt.want) > 1e-6 {
+				t.Errorf("Target(%s) = %v, want %v", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTargetScheduleUnthrottled(t *testing.T) {
+	s, err := newTargetSchedule(properties.NewProperties())
+	if err != nil || s != nil {
+		t.Errorf("got schedule %v and error %v, want none", s, err)
+	}
+
+	p := properties.LoadMap(map[string]string{prop.TargetSchedule: "ramp"})
+	if _, err := newTargetSchedule(p); err == nil {
+		t.Errorf("ramp without duration should fail")
+	}
+}
+
+func TestScheduleAdvance(t *testing.T) {
+	// 4 threads sharing 100 ops/s do one operation every 40ms each.
+	if got := scheduleAdvance(constantSchedule(100), 4, time.Second, 1); got != time.Second+40*time.Millisecond {
+		t.Errorf("constant advance = %s, want 1.04s", got)
+	}
+
+	// At 1 op/s for the first second and 10 ops/s after, a batch of 1.5
+	// operations takes the first second plus half an operation at 10 ops/s.
+	s := stepSchedule{start: 1, increment: 9, interval: time.Second, max: 10}
+	got := scheduleAdvance(s, 1, 0, 1.5)
+	if want := 1050 * time.Millisecond; got < want-time.Millisecond || got > want+time.Millisecond {
+		t.Errorf("step advance = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// progress returns the operations done after the warm-up and the ones the
+// running workers still have to do. Stopped workers don't count as remaining.
+func (c *Client) progress() (done int64, remaining int64) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, w := range c.allWorkers {
+		done += atomic.LoadInt64(&w.opsDone)
+	}
+	for _, w := range c.workers {
+		if left := atomic.LoadInt64(&w.opCount) - atomic.LoadInt64(&w.opsDone); left > 0 {
+			remaining += left
+		}
+	}
+	return done, remaining
+}
+
+// statusLine is a progress report, it is printed as is with status.format=json.
+type statusLine struct {
+	Time           string  `json:"time"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	OpsDone        int64   `json:"ops_done"`
+	OpsTotal       int64   `json:"ops_total"`
+	Progress       float64 `json:"progress"`
+	OpsPerSec      float64 `json:"ops_per_sec"`
+	// ETASeconds is -1 while no estimate is possible.
+	ETASeconds float64 `json:"eta_seconds"`
+}
+
+// statusReporter prints the progress, the throughput since its previous report
+// and the estimated remaining time.
+type statusReporter struct {
+	c      *Client
+	format string
+
+	lastOps int64
+	lastAt  time.Time
+}
+
+func newStatusReporter(c *Client, p *properties.Properties) (*statusReporter, error) {
+	format := p.GetString(prop.StatusFormat, prop.StatusFormatDefault)
+	switch format {
+	case "none":
+		return nil, nil
+	case "text", "json":
+	default:
+		return nil, fmt.Errorf("unknown %s %q, must be text, json or none", prop.StatusFormat, format)
+	}
+	return &statusReporter{c: c, format: format}, nil
+}
+
+// start marks the beginning of the first interval.
+func (r *statusReporter) start(now time.Time) {
+	r.lastOps, _ = r.c.progress()
+	r.lastAt = now
+}
+
+func (r *statusReporter) report(w io.Writer, now time.Time) {
+	done, remaining := r.c.progress()
+
+	var opsPerSec float64
+	if d := now.Sub(r.lastAt).Seconds(); d > 0 {
+		opsPerSec = float64(done-r.lastOps) / d
+	}
+	r.lastOps, r.lastAt = done, now
+
+	var untilDeadline time.Duration = -1
+	if deadline, ok := r.c.stopCtx.Deadline(); ok {
+		untilDeadline = deadline.Sub(now)
+	}
+	eta := estimateETA(remaining, opsPerSec, untilDeadline)
+
+	line := statusLine{
+		Time:           now.Format(time.RFC3339),
+		ElapsedSeconds: round(now.Sub(r.c.start).Seconds(), 1),
+		OpsDone:        done,
+		OpsTotal:       done + remaining,
+		OpsPerSec:      round(opsPerSec, 1),
+		ETASeconds:     -1,
+	}
+	if line.OpsTotal > 0 {
+		line.Progress = round(float64(done)/float64(line.OpsTotal), 4)
+	}
+	if eta >= 0 {
+		line.ETASeconds = round(eta.Seconds(), 1)
+	}
+
+	if r.format == "json" {
+		b, _ := json.Marshal(line)
+		fmt.Fprintf(w, "%s\n", b)
+		return
+	}
+
+	etaText := "unknown"
+	if eta >= 0 {
+		etaText = eta.Round(time.Second).String()
+	}
+	fmt.Fprintf(w, "Progress: %.1f%% (%d/%d), %.1f ops/sec, ETA %s\n",
+		line.Progress*100, done, line.OpsTotal, line.OpsPerSec, etaText)
+}
+
+// estimateETA returns the time needed for the remaining operations at
+// opsPerSec, bounded by the time left until the deadline if there is one
+// (untilDeadline >= 0). It returns -1 if there is no estimate.
+func estimateETA(remaining int64, opsPerSec float64, untilDeadline time.Duration) time.Duration {
+	eta := time.Duration(-1)
+	if remaining == 0 {
+		eta = 0
+	} else if opsPerSec > 0 {
+		eta = time.Duration(float64(remaining) / opsPerSec * float64(time.Second))
+	}
+
+	if untilDeadline >= 0 && (eta < 0 || untilDeadline < eta) {
+		eta = untilDeadline
+	}
+	return eta
+}
+
+func round(v float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(v*scale) / scale
+}
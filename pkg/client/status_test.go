@@ -0,0 +1,43 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateETA(t *testing.T) {
+	tests := []struct {
+		remaining     int64
+		opsPerSec     float64
+		untilDeadline time.Duration
+		want          time.Duration
+	}{
+		{remaining: 1000, opsPerSec: 100, untilDeadline: -1, want: 10 * time.Second},
+		{remaining: 0, opsPerSec: 0, untilDeadline: -1, want: 0},
+		// stalled run without deadline
+		{remaining: 1000, opsPerSec: 0, untilDeadline: -1, want: -1},
+		// the deadline comes first
+		{remaining: 1000, opsPerSec: 100, untilDeadline: 5 * time.Second, want: 5 * time.Second},
+		{remaining: 1000, opsPerSec: 0, untilDeadline: 5 * time.Second, want: 5 * time.Second},
+		{remaining: 1000, opsPerSec: 100, untilDeadline: time.Minute, want: 10 * time.Second},
+	}
+
+	for i, test := range tests {
+		if got := estimateETA(test.remaining, test.opsPerSec, test.untilDeadline); got != test.want {
+			t.Errorf("test %d: got ETA %s, want %s", i, got, test.want)
+		}
+	}
+}
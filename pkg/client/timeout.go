@@ -0,0 +1,61 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// Timeouts are the deadlines of a single attempt of every operation, by
+// operation name. Operations without a timeout only end with the run.
+type Timeouts map[string]time.Duration
+
+// NewTimeouts creates the timeouts configured by the timeout.* properties.
+func NewTimeouts(p *properties.Properties) (Timeouts, error) {
+	keys := map[string]string{
+		"READ":   prop.TimeoutRead,
+		"SCAN":   prop.TimeoutScan,
+		"UPDATE": prop.TimeoutUpdate,
+		"INSERT": prop.TimeoutInsert,
+		"DELETE": prop.TimeoutDelete,
+	}
+
+	t := Timeouts{}
+	for op, key := range keys {
+		v, ok := p.Get(key)
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %v", key, v, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("%s must be positive, got %s", key, v)
+		}
+		t[op] = d
+	}
+	return t, nil
+}
+
+// get returns the timeout of op, batch operations use the one of their
+// single operation. It returns 0 if op has no timeout.
+func (t Timeouts) get(op string) time.Duration {
+	return t[strings.TrimPrefix(op, "BATCH_")]
+}
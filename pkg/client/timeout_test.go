@@ -0,0 +1,106 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// hangDB blocks the first hangs reads until they are cancelled.
+type hangDB struct {
+	sleepDB
+	hangs int
+	calls int
+}
+
+func (d *hangDB) Read(ctx context.Context, _ string, _ string, _ []string) (map[string][]byte, error) {
+	d.calls++
+	if d.calls <= d.hangs {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return nil, nil
+}
+
+func TestNewTimeouts(t *testing.T) {
+	timeouts, err := NewTimeouts(properties.LoadMap(map[string]string{prop.TimeoutRead: "50ms"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := timeouts.get("BATCH_READ"); got != 50*time.Millisecond {
+		t.Errorf("got BATCH_READ timeout %s, want the READ one", got)
+	}
+	if got := timeouts.get("UPDATE"); got != 0 {
+		t.Errorf("got UPDATE timeout %s, want none", got)
+	}
+
+	for _, v := range []string{"abc", "0s", "-1s"} {
+		if _, err := NewTimeouts(properties.LoadMap(map[string]string{prop.TimeoutScan: v})); err == nil {
+			t.Errorf("timeout %q should be invalid", v)
+		}
+	}
+}
+
+func TestDbWrapperTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		hangs       int
+		wantErr     bool
+		// want lists the measured operations, with their count
+		want map[string]int64
+	}{
+		{"fast", 1, 0, false, map[string]int64{"READ": 1}},
+		{"timed out", 1, 1, true, map[string]int64{"READ_TIMEOUT": 1}},
+		{"retried", 3, 1, false, map[string]int64{"READ": 1, "READ_RETRY": 1, "READ_FINAL_ATTEMPT": 1}},
+		{"retries timed out", 2, 2, true, map[string]int64{"READ_TIMEOUT": 1, "READ_RETRY": 1}},
+	}
+	for _, test := range tests {
+		newTestProperties()
+		policy := &RetryPolicy{MaxAttempts: test.maxAttempts, InitialBackoff: time.Millisecond, Multiplier: 2}
+		db := DbWrapper{
+			DB:       &hangDB{hangs: test.hangs},
+			Retry:    policy,
+			Timeouts: Timeouts{"READ": 20 * time.Millisecond},
+		}
+
+		_, err := db.Read(context.Background(), "t", "k", nil)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got err %v, want error %v", test.name, err, test.wantErr)
+		}
+
+		got := make(map[string]int64)
+		for op, h := range measurement.Histograms() {
+			if op != "TOTAL" {
+				got[op] = h.TotalCount()
+			}
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%s: got measurements %v, want %v", test.name, got, test.want)
+			continue
+		}
+		for op, n := range test.want {
+			if got[op] != n {
+				t.Errorf("%s: got measurements %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+	}
+}
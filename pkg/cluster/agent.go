@@ -0,0 +1,117 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// Runner runs the phases an agent is asked for.
+type Runner interface {
+	// Prepare sets the phase up, eg: creates the DB and the workload.
+	Prepare(req *PhaseRequest) error
+	// Run runs the prepared phase until it ends or ctx is done.
+	Run(ctx context.Context) *PhaseResult
+}
+
+type agent struct {
+	name   string
+	runner Runner
+
+	mu       sync.Mutex
+	prepared bool
+	// cancel stops the running phase, nil if no phase is running.
+	cancel context.CancelFunc
+}
+
+// NewAgentHandler returns the handler of an agent named name, served under /agent/:
+//
+//	POST /agent/prepare  prepare a phase, the body is a PhaseRequest
+//	POST /agent/start    run the prepared phase and reply its PhaseResult once it ends
+//	POST /agent/stop     stop the running phase
+func NewAgentHandler(name string, runner Runner) http.Handler {
+	a := &agent{name: name, runner: runner}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/agent/prepare", postOnly(a.prepare))
+	mux.HandleFunc("/agent/start", postOnly(a.start))
+	mux.HandleFunc("/agent/stop", postOnly(a.stop))
+	return mux
+}
+
+func postOnly(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "POST only", http.StatusMethodNotAllowed)
+			return
+		}
+		fn(w, r)
+	}
+}
+
+func (a *agent) prepare(w http.ResponseWriter, r *http.Request) {
+	var req PhaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cancel != nil {
+		http.Error(w, "a phase is running", http.StatusConflict)
+		return
+	}
+	if err := a.runner.Prepare(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.prepared = true
+}
+
+func (a *agent) start(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	if !a.prepared || a.cancel != nil {
+		a.mu.Unlock()
+		http.Error(w, "no phase prepared", http.StatusConflict)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.prepared = false
+	a.cancel = cancel
+	a.mu.Unlock()
+
+	result := a.runner.Run(ctx)
+	result.Agent = a.name
+
+	a.mu.Lock()
+	a.cancel = nil
+	a.mu.Unlock()
+	cancel()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+func (a *agent) stop(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.cancel != nil {
+		a.cancel()
+	}
+}
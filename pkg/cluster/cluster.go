@@ -0,0 +1,198 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cluster runs a benchmark phase on several go-ycsb agents at once.
+// The coordinator splits the key range, the threads and the target between
+// the agents, starts the phase on all of them together and merges the latency
+// histograms they return.
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// PhaseRequest prepares an agent for a phase.
+type PhaseRequest struct {
+	DB         string            `json:"db"`
+	Phase      string            `json:"phase"`
+	Properties map[string]string `json:"properties"`
+}
+
+// PhaseResult is what an agent measured during a phase.
+type PhaseResult struct {
+	Agent   string        `json:"agent"`
+	Elapsed time.Duration `json:"elapsed"`
+	// Histograms are the encoded latency histograms (in us) of each operation.
+	Histograms map[string][]byte `json:"histograms"`
+	// Error is set if the phase failed or was aborted on the agent.
+	Error string `json:"error,omitempty"`
+}
+
+// EncodeHistograms encodes histograms for a PhaseResult.
+func EncodeHistograms(hists map[string]*hdrhistogram.Histogram) (map[string][]byte, error) {
+	encoded := make(map[string][]byte, len(hists))
+	for op, hist := range hists {
+		buf, err := hist.Encode(hdrhistogram.V2CompressedEncodingCookieBase)
+		if err != nil {
+			return nil, fmt.Errorf("encode %s histogram failed %v", op, err)
+		}
+		encoded[op] = buf
+	}
+	return encoded, nil
+}
+
+// mergeHistograms decodes the histograms of every result and merges them by operation.
+func mergeHistograms(results []*PhaseResult) (map[string]*hdrhistogram.Histogram, error) {
+	merged := make(map[string]*hdrhistogram.Histogram)
+	for _, result := range results {
+		for op, buf := range result.Histograms {
+			hist, err := hdrhistogram.Decode(buf)
+			if err != nil {
+				return nil, fmt.Errorf("decode %s histogram of agent %s failed %v", op, result.Agent, err)
+			}
+			if m, ok := merged[op]; ok {
+				m.Merge(hist)
+			} else {
+				merged[op] = hist
+			}
+		}
+	}
+	return merged, nil
+}
+
+// targetKeys are the properties in ops/sec that are divided between agents.
+var targetKeys = []string{
+	prop.Target,
+	prop.TargetRampFrom,
+	prop.TargetRampTo,
+	prop.TargetStepStart,
+	prop.TargetStepIncrement,
+	prop.TargetStepMax,
+	prop.TargetSineBase,
+	prop.TargetSineAmplitude,
+}
+
+// share returns the part of total given to the i-th of n agents, the first
+// ones taking the remainder.
+func share(total int64, i int, n int) int64 {
+	s := total / int64(n)
+	if int64(i) < total%int64(n) {
+		s++
+	}
+	return s
+}
+
+// Split returns the properties of each of n agents for a phase described by p:
+//   - threadcount and operationcount are divided between the agents,
+//   - when loading, the [insertstart, insertstart+insertcount) key range is
+//     cut in contiguous slices so agents never load the same key. Run phases
+//     keep the whole range for every agent to read from, so the request
+//     distribution is the one of a single client,
+//   - run phases insert from transactioninsertstart, recordcount by default,
+//     in one range per agent as large as its operationcount share, or
+//     math.MaxInt32 keys without an operationcount,
+//   - the targets are divided in proportion to the agent threads,
+//   - the seed is offset by the agent index, so agents draw different values,
+//   - histogram is added to the measurement types, for the agents to report
+//     their histograms.
+func Split(p *properties.Properties, n int) ([]*properties.Properties, error) {
+	threadCount := p.GetInt64(prop.ThreadCount, 1)
+	if threadCount < int64(n) {
+		return nil, fmt.Errorf("%s %d is smaller than the agent count %d", prop.ThreadCount, threadCount, n)
+	}
+	if p.GetString(prop.TargetSchedule, prop.TargetScheduleDefault) == "file" {
+		return nil, fmt.Errorf("%s=file can't be divided between agents", prop.TargetSchedule)
+	}
+
+	load := !p.GetBool(prop.DoTransactions, true)
+	recordCount := p.GetInt64(prop.RecordCount, prop.RecordCountDefault)
+	if recordCount == 0 {
+		recordCount = int64(math.MaxInt32)
+	}
+	insertStart := p.GetInt64(prop.InsertStart, prop.InsertStartDefault)
+	insertCount := p.GetInt64(prop.InsertCount, recordCount-insertStart)
+	_, hasOperationCount := p.Get(prop.OperationCount)
+	operationCount := p.GetInt64(prop.OperationCount, 0)
+	transactionInsertStart := p.GetInt64(prop.TransactionInsertStart, recordCount)
+	_, hasSeed := p.Get(prop.Seed)
+	seed := p.GetInt64(prop.Seed, 0)
+	measurementType := agentMeasurementType(p.GetString(prop.MeasurementType, prop.MeasurementTypeDefault))
+
+	agents := make([]*properties.Properties, 0, n)
+	for i := 0; i < n; i++ {
+		ap := properties.NewProperties()
+		ap.Merge(p)
+
+		threads := share(threadCount, i, n)
+		ap.Set(prop.ThreadCount, strconv.FormatInt(threads, 10))
+
+		if load {
+			ap.Set(prop.InsertStart, strconv.FormatInt(insertStart, 10))
+			ap.Set(prop.InsertCount, strconv.FormatInt(share(insertCount, i, n), 10))
+			insertStart += share(insertCount, i, n)
+		} else {
+			// an agent inserts at most one key per operation
+			ap.Set(prop.TransactionInsertStart, strconv.FormatInt(transactionInsertStart, 10))
+			if operationCount > 0 {
+				transactionInsertStart += share(operationCount, i, n)
+			} else {
+				transactionInsertStart += math.MaxInt32
+			}
+		}
+
+		if hasSeed {
+			ap.Set(prop.Seed, strconv.FormatInt(seed+int64(i), 10))
+		}
+
+		if hasOperationCount {
+			ap.Set(prop.OperationCount, strconv.FormatInt(share(operationCount, i, n), 10))
+		}
+
+		for _, key := range targetKeys {
+			if _, ok := p.Get(key); ok {
+				target := p.GetFloat64(key, 0) * float64(threads) / float64(threadCount)
+				ap.Set(key, strconv.FormatFloat(target, 'f', -1, 64))
+			}
+		}
+
+		if measurementType != ap.GetString(prop.MeasurementType, prop.MeasurementTypeDefault) {
+			ap.Set(prop.MeasurementType, measurementType)
+			// the added histogram prints to stdout instead of sharing the
+			// output file of the other types
+			if _, ok := p.Get(prop.MeasurementHistogramOutputFile); !ok {
+				ap.Set(prop.MeasurementHistogramOutputFile, "")
+			}
+		}
+		agents = append(agents, ap)
+	}
+	return agents, nil
+}
+
+// agentMeasurementType returns the measurement types with histogram added, the
+// agents report their histograms to the coordinator.
+func agentMeasurementType(types string) string {
+	for _, name := range strings.Split(types, ",") {
+		if strings.TrimSpace(name) == "histogram" {
+			return types
+		}
+	}
+	return types + ",histogram"
+}
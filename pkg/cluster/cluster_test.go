@@ -0,0 +1,240 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	_ "github.com/pingcap/go-ycsb/pkg/workload"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		props  map[string]string
+		agents int
+		// want lists the expected value of each agent for every key
+		want map[string][]string
+	}{
+		{
+			props: map[string]string{
+				prop.DoTransactions: "false",
+				prop.RecordCount:    "1000",
+				prop.ThreadCount:    "8",
+			},
+			agents: 3,
+			want: map[string][]string{
+				prop.ThreadCount: {"3", "3", "2"},
+				prop.InsertStart: {"0", "334", "667"},
+				prop.InsertCount: {"334", "333", "333"},
+			},
+		},
+		{
+			props: map[string]string{
+				prop.DoTransactions: "false",
+				prop.RecordCount:    "1000",
+				prop.InsertStart:    "100",
+				prop.InsertCount:    "500",
+				prop.ThreadCount:    "2",
+			},
+			agents: 2,
+			want: map[string][]string{
+				prop.InsertStart: {"100", "350"},
+				prop.InsertCount: {"250", "250"},
+			},
+		},
+		{
+			props: map[string]string{
+				prop.RecordCount:    "1000",
+				prop.OperationCount: "10001",
+				prop.ThreadCount:    "3",
+				prop.Target:         "3000",
+				prop.TargetRampTo:   "6000",
+			},
+			agents: 2,
+			want: map[string][]string{
+				prop.ThreadCount:    {"2", "1"},
+				prop.OperationCount: {"5001", "5000"},
+				prop.Target:         {"2000", "1000"},
+				prop.TargetRampTo:   {"4000", "2000"},
+				// run phases read from the whole key range
+				prop.InsertStart:            {"", ""},
+				prop.TransactionInsertStart: {"1000", "6001"},
+				prop.Seed:                   {"", ""},
+				prop.MeasurementType:        {"", ""},
+			},
+		},
+		{
+			props: map[string]string{
+				prop.RecordCount:     "1000",
+				prop.InsertStart:     "200",
+				prop.ThreadCount:     "3",
+				prop.Seed:            "42",
+				prop.MeasurementType: "raw",
+			},
+			agents: 3,
+			want: map[string][]string{
+				prop.InsertStart:                    {"200", "200", "200"},
+				prop.InsertCount:                    {"", "", ""},
+				prop.TransactionInsertStart:         {"1000", "2147484647", "4294968294"},
+				prop.Seed:                           {"42", "43", "44"},
+				prop.MeasurementType:                {"raw,histogram", "raw,histogram", "raw,histogram"},
+				prop.MeasurementHistogramOutputFile: {"", "", ""},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		p := properties.LoadMap(test.props)
+		agents, err := Split(p, test.agents)
+		if err != nil {
+			t.Fatalf("split %d failed %v", i, err)
+		}
+		for key, want := range test.want {
+			got := make([]string, 0, len(agents))
+			for _, ap := range agents {
+				got = append(got, ap.GetString(key, ""))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("split %d: got %s %v, want %v", i, key, got, want)
+			}
+		}
+	}
+
+	if _, err := Split(properties.LoadMap(map[string]string{prop.ThreadCount: "2"}), 3); err == nil {
+		t.Errorf("split 2 threads between 3 agents should fail")
+	}
+}
+
+// insertDB records the inserted keys.
+type insertDB struct {
+	ycsb.DB
+	keys map[string]int
+}
+
+func (db insertDB) InitThread(ctx context.Context, _ int, _ int) context.Context {
+	return ctx
+}
+
+func (db insertDB) CleanupThread(_ context.Context) {
+}
+
+func (db insertDB) Insert(_ context.Context, _ string, key string, _ map[string][]byte) error {
+	db.keys[key]++
+	return nil
+}
+
+func TestSplitInserts(t *testing.T) {
+	p := properties.LoadMap(map[string]string{
+		prop.RecordCount:      "1000",
+		prop.OperationCount:   "200",
+		prop.ThreadCount:      "2",
+		prop.ReadProportion:   "0",
+		prop.UpdateProportion: "0",
+		prop.InsertProportion: "1",
+	})
+	agents, err := Split(p, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := insertDB{keys: make(map[string]int)}
+	for _, ap := range agents {
+		workload, err := ycsb.GetWorkloadCreator("core").Create(ap)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := workload.InitThread(context.Background(), 0, 1)
+		for i := int64(0); i < ap.GetInt64(prop.OperationCount, 0); i++ {
+			if err := workload.DoTransaction(ctx, db); err != nil {
+				t.Fatal(err)
+			}
+		}
+		workload.CleanupThread(ctx)
+		workload.Close()
+	}
+
+	if len(db.keys) != 200 {
+		t.Errorf("got %d distinct keys inserted by the agents, want 200", len(db.keys))
+	}
+}
+
+// fakeRunner records one READ per thread, each taking as many ms as there are
+// threads, and reports as many seconds elapsed.
+type fakeRunner struct {
+	p *properties.Properties
+}
+
+func (r *fakeRunner) Prepare(req *PhaseRequest) error {
+	if req.DB != "basic" {
+		return fmt.Errorf("unknown db %s", req.DB)
+	}
+	r.p = properties.LoadMap(req.Properties)
+	return nil
+}
+
+func (r *fakeRunner) Run(ctx context.Context) *PhaseResult {
+	threads := r.p.GetInt64(prop.ThreadCount, 1)
+	hist := hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	hist.RecordValues(threads*1000, threads)
+
+	hists, err := EncodeHistograms(map[string]*hdrhistogram.Histogram{"READ": hist})
+	if err != nil {
+		return &PhaseResult{Error: err.Error()}
+	}
+	return &PhaseResult{Elapsed: time.Duration(threads) * time.Second, Histograms: hists}
+}
+
+func TestCoordinator(t *testing.T) {
+	var agents []string
+	for i := 0; i < 3; i++ {
+		s := httptest.NewServer(NewAgentHandler(strconv.Itoa(i), &fakeRunner{}))
+		defer s.Close()
+		agents = append(agents, strings.TrimPrefix(s.URL, "http://"))
+	}
+	c := NewCoordinator(agents)
+
+	p := properties.LoadMap(map[string]string{prop.ThreadCount: "7"})
+	result, err := c.RunPhase(context.Background(), "basic", "run", p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := result.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if result.Elapsed != 3*time.Second {
+		t.Errorf("got elapsed %s, want the slowest agent 3s", result.Elapsed)
+	}
+	read := result.Histograms["READ"]
+	if read == nil {
+		t.Fatalf("missing merged READ histogram")
+	}
+	if read.TotalCount() != 7 || !read.ValuesAreEquivalent(read.Min(), 2000) || !read.ValuesAreEquivalent(read.Max(), 3000) {
+		t.Errorf("got merged READ count %d min %d max %d, want 7 values within [2000, 3000]", read.TotalCount(), read.Min(), read.Max())
+	}
+
+	if _, err := c.RunPhase(context.Background(), "unknown", "run", p); err == nil {
+		t.Errorf("preparing an unknown db should fail")
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+)
+
+// stopTimeout bounds the stop requests sent when the coordinator is interrupted.
+const stopTimeout = 10 * time.Second
+
+// Coordinator runs phases on a set of agents.
+type Coordinator struct {
+	agents []string
+	client *http.Client
+}
+
+// Result is the outcome of a phase over all agents.
+type Result struct {
+	// Elapsed is the duration of the slowest agent.
+	Elapsed time.Duration
+	// Histograms are the latency histograms (in us) of all agents merged by operation.
+	Histograms map[string]*hdrhistogram.Histogram
+	Agents     []*PhaseResult
+}
+
+// Err returns the errors reported by the agents, if any.
+func (r *Result) Err() error {
+	var errs []string
+	for _, agent := range r.Agents {
+		if agent.Error != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", agent.Agent, agent.Error))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// NewCoordinator returns a coordinator of the agents at the given host:port addresses.
+func NewCoordinator(agents []string) *Coordinator {
+	return &Coordinator{agents: agents, client: &http.Client{}}
+}
+
+func (c *Coordinator) post(ctx context.Context, agent string, path string, in interface{}, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+agent+path, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s %s: %s", path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// each calls fn for every agent concurrently and returns the first error.
+func (c *Coordinator) each(fn func(i int, agent string) error) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(c.agents))
+	for i, agent := range c.agents {
+		wg.Add(1)
+		go func(i int, agent string) {
+			defer wg.Done()
+			if err := fn(i, agent); err != nil {
+				errs[i] = fmt.Errorf("agent %s: %v", agent, err)
+			}
+		}(i, agent)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunPhase splits the phase described by p between the agents, prepares all
+// of them, then starts them together and waits until every agent is done. If
+// ctx is done meanwhile, the agents are stopped and report what they measured.
+func (c *Coordinator) RunPhase(ctx context.Context, db string, phase string, p *properties.Properties) (*Result, error) {
+	agentProps, err := Split(p, len(c.agents))
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.each(func(i int, agent string) error {
+		req := &PhaseRequest{DB: db, Phase: phase, Properties: agentProps[i].Map()}
+		return c.post(ctx, agent, "/agent/prepare", req, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			stopCtx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+			defer cancel()
+			c.each(func(_ int, agent string) error {
+				return c.post(stopCtx, agent, "/agent/stop", nil, nil)
+			})
+		}
+	}()
+
+	result := &Result{Agents: make([]*PhaseResult, len(c.agents))}
+	// The start requests last as long as the phase, interrupting is done with
+	// the stop requests above so that the agents still reply their results.
+	err = c.each(func(i int, agent string) error {
+		result.Agents[i] = new(PhaseResult)
+		return c.post(context.Background(), agent, "/agent/start", nil, result.Agents[i])
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, agent := range result.Agents {
+		if agent.Elapsed > result.Elapsed {
+			result.Elapsed = agent.Elapsed
+		}
+	}
+	if result.Histograms, err = mergeHistograms(result.Agents); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -34,7 +34,6 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
-	"time"
 
 	"github.com/pingcap/go-ycsb/pkg/util"
 )
@@ -112,7 +111,8 @@ func NewZipfian(min int64, max int64, zipfianConstant float64, zetan float64) *Z
 	z.countForZeta = items
 	z.eta = (1 - math.Pow(2.0/float64(items), 1-theta)) / (1 - z.zeta2Theta/z.zetan)
 
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	// The priming value is never used, a fixed seed keeps the construction deterministic.
+	r := rand.New(rand.NewSource(0))
 	z.Next(r)
 	return z
 }
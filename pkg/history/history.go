@@ -0,0 +1,266 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/boltdb/bolt"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+var runsBucket = []byte("runs")
+
+// runKeyLayout sorts the runs by start time, run IDs are only to the second.
+const runKeyLayout = "20060102T150405.000000000"
+
+func runKey(start time.Time) []byte {
+	return []byte(start.UTC().Format(runKeyLayout))
+}
+
+// volatileProperties are the prefixes of the properties that don't change
+// what is benchmarked, they are left out of the properties hash.
+var volatileProperties = []string{
+	"label", "debug.", "history.", "measurement.", "assert.", "status.", "outputstyle", "silence",
+}
+
+// Series identifies the runs of one benchmark with the same properties.
+type Series struct {
+	Name string
+	Hash string
+}
+
+func (s Series) key() []byte {
+	return []byte(s.Name + "/" + s.Hash)
+}
+
+func (s Series) String() string {
+	return s.Name + "/" + s.Hash
+}
+
+// SeriesOf returns the series of a run: the name is the benchmark name if not
+// empty, else the label, else the DB, and the hash is the one of the
+// properties defining the benchmark.
+func SeriesOf(benchmark string, r *measurement.Result) Series {
+	name := benchmark
+	if name == "" {
+		name = r.Label
+	}
+	if name == "" {
+		name = r.DB
+	}
+
+	keys := make([]string, 0, len(r.Properties))
+	for key := range r.Properties {
+		volatile := false
+		for _, prefix := range volatileProperties {
+			if strings.HasPrefix(key, prefix) {
+				volatile = true
+				break
+			}
+		}
+		if !volatile {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s=%s\n", key, r.Properties[key])
+	}
+	return Series{Name: name, Hash: hex.EncodeToString(h.Sum(nil))[:12]}
+}
+
+// Store keeps the results of past runs in a Bolt file, grouped by series and
+// ordered by start time.
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the store, creating the file if needed.
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save stores the result of a run in its series.
+func (s *Store) Save(series Series, r *measurement.Result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		runs, err := tx.CreateBucketIfNotExists(runsBucket)
+		if err != nil {
+			return err
+		}
+		b, err := runs.CreateBucketIfNotExists(series.key())
+		if err != nil {
+			return err
+		}
+		return b.Put(append(runKey(r.StartTime), r.RunID...), data)
+	})
+}
+
+// Series returns all the series, sorted by name and hash.
+func (s *Store) Series() ([]Series, error) {
+	var series []Series
+	err := s.db.View(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		if runs == nil {
+			return nil
+		}
+		return runs.ForEach(func(k, _ []byte) error {
+			key := string(k)
+			i := strings.LastIndex(key, "/")
+			series = append(series, Series{Name: key[:i], Hash: key[i+1:]})
+			return nil
+		})
+	})
+	return series, err
+}
+
+// Runs returns up to limit results of the series started before the time
+// before, oldest first. A zero before takes the latest runs, a limit <= 0 takes
+// them all.
+func (s *Store) Runs(series Series, before time.Time, limit int) ([]*measurement.Result, error) {
+	var results []*measurement.Result
+	err := s.db.View(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		if runs == nil {
+			return nil
+		}
+		b := runs.Bucket(series.key())
+		if b == nil {
+			return nil
+		}
+
+		c := b.Cursor()
+		var k, v []byte
+		if before.IsZero() {
+			k, v = c.Last()
+		} else if k, _ = c.Seek(runKey(before)); k == nil {
+			// all the runs started before
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		for ; k != nil && (limit <= 0 || len(results) < limit); k, v = c.Prev() {
+			r := new(measurement.Result)
+			if err := json.Unmarshal(v, r); err != nil {
+				return fmt.Errorf("run %s: %v", k, err)
+			}
+			results = append(results, r)
+		}
+		return nil
+	})
+
+	// oldest first
+	for i, j := 0, len(results)-1; i < j; i, j = i+1, j-1 {
+		results[i], results[j] = results[j], results[i]
+	}
+	return results, err
+}
+
+// Baseline returns a result whose operation stats are the medians of the ones
+// of the runs, nil if there are none.
+func Baseline(runs []*measurement.Result) *measurement.Result {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	values := make(map[string]map[string][]float64)
+	for _, r := range runs {
+		for _, o := range r.Operations {
+			stats, ok := values[o.Name]
+			if !ok {
+				stats = make(map[string][]float64)
+				values[o.Name] = stats
+			}
+			for _, stat := range baselineStats {
+				if v, ok := o.Stat(stat); ok {
+					stats[stat] = append(stats[stat], v)
+				}
+			}
+		}
+	}
+
+	baseline := &measurement.Result{RunID: fmt.Sprintf("median of %d runs", len(runs))}
+	for op, stats := range values {
+		o := measurement.OperationResult{Name: op}
+		for stat, vs := range stats {
+			setStat(&o, stat, median(vs))
+		}
+		baseline.Operations = append(baseline.Operations, o)
+	}
+	sort.Slice(baseline.Operations, func(i, j int) bool { return baseline.Operations[i].Name < baseline.Operations[j].Name })
+	return baseline
+}
+
+var baselineStats = []string{"count", "errors", "timeouts", "ops", "avg_us", "min_us", "max_us",
+	"p50_us", "p90_us", "p95_us", "p99_us", "p999_us", "p9999_us"}
+
+func setStat(o *measurement.OperationResult, stat string, v float64) {
+	switch stat {
+	case "count":
+		o.Count = int64(v)
+	case "errors":
+		o.Errors = int64(v)
+	case "timeouts":
+		o.Timeouts = int64(v)
+	case "ops":
+		o.OpsPerSec = v
+	case "avg_us":
+		o.AvgUs = v
+	case "min_us":
+		o.MinUs = int64(v)
+	case "max_us":
+		o.MaxUs = int64(v)
+	case "p50_us":
+		o.P50Us = int64(v)
+	case "p90_us":
+		o.P90Us = int64(v)
+	case "p95_us":
+		o.P95Us = int64(v)
+	case "p99_us":
+		o.P99Us = int64(v)
+	case "p999_us":
+		o.P999Us = int64(v)
+	case "p9999_us":
+		o.P9999Us = int64(v)
+	}
+}
+
+func median(vs []float64) float64 {
+	sort.Float64s(vs)
+	n := len(vs)
+	if n%2 == 1 {
+		return vs[n/2]
+	}
+	return (vs[n/2-1] + vs[n/2]) / 2
+}
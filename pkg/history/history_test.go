@@ -0,0 +1,126 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+func TestSeriesOf(t *testing.T) {
+	r := &measurement.Result{DB: "fdb", Label: "nightly", Properties: map[string]string{
+		"recordcount": "1000",
+		"label":       "nightly",
+	}}
+	other := &measurement.Result{DB: "fdb", Properties: map[string]string{
+		"recordcount":             "1000",
+		"measurement.result_file": "result.json",
+		"history.path":            "history.db",
+	}}
+	changed := &measurement.Result{DB: "fdb", Properties: map[string]string{
+		"recordcount": "2000",
+	}}
+
+	s := SeriesOf("", r)
+	if s.Name != "nightly" {
+		t.Errorf("got name %q, want the label", s.Name)
+	}
+	if o := SeriesOf("", other); o.Name != "fdb" || o.Hash != s.Hash {
+		t.Errorf("got %s, want fdb with the hash of %s", o, s)
+	}
+	if c := SeriesOf("bench", changed); c.Name != "bench" || c.Hash == s.Hash {
+		t.Errorf("got %s, want bench with another hash than %s", c, s)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// the runs are ordered by start time, not by run ID
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Millisecond) }
+	series := Series{Name: "bench", Hash: "abc"}
+	for i, id := range []string{"run1", "run4", "run2", "run3"} {
+		r := &measurement.Result{RunID: id, StartTime: at(i + 1)}
+		if err := store.Save(series, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Save(Series{Name: "other", Hash: "def"}, &measurement.Result{RunID: "run9", StartTime: at(9)}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 2 || all[0] != series {
+		t.Errorf("got series %v, want bench/abc and other/def", all)
+	}
+
+	tests := []struct {
+		before time.Time
+		limit  int
+		want   []string
+	}{
+		{time.Time{}, 0, []string{"run1", "run4", "run2", "run3"}},
+		{time.Time{}, 2, []string{"run2", "run3"}},
+		{at(4), 2, []string{"run4", "run2"}},
+		{at(3), 0, []string{"run1", "run4"}},
+		{at(5), 0, []string{"run1", "run4", "run2", "run3"}},
+		{at(1), 0, nil},
+	}
+	for _, test := range tests {
+		runs, err := store.Runs(series, test.before, test.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range runs {
+			got = append(got, r.RunID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("before %s limit %d: got %v, want %v", test.before, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	if Baseline(nil) != nil {
+		t.Errorf("got a baseline without runs")
+	}
+
+	var runs []*measurement.Result
+	for _, v := range []int64{300, 100, 200, 1000} {
+		runs = append(runs, &measurement.Result{Operations: []measurement.OperationResult{
+			{Name: "READ", Count: v, OpsPerSec: float64(v), P99Us: v},
+		}})
+	}
+	baseline := Baseline(runs)
+	read := baseline.Operation("READ")
+	if read == nil || read.Count != 250 || read.OpsPerSec != 250 || read.P99Us != 250 {
+		t.Errorf("got %+v, want the medians 250", read)
+	}
+	if baseline = Baseline(runs[:3]); baseline.Operation("READ").P99Us != 200 {
+		t.Errorf("got %+v, want the median 200", baseline.Operation("READ"))
+	}
+}
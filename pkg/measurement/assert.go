@@ -0,0 +1,223 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// Assertion is a check of the final results, eg: READ.p99_us<=5000. Without
+// an operation it checks the whole run, eg: errors==0.
+type Assertion struct {
+	Expr      string
+	Operation string
+	Stat      string
+	Cmp       string
+	Value     float64
+}
+
+// AssertionResult is the outcome of an assertion, Actual is nil if the stat
+// was not measured, which fails the assertion.
+type AssertionResult struct {
+	Assertion string   `json:"assertion"`
+	Actual    *float64 `json:"actual"`
+	Passed    bool     `json:"passed"`
+}
+
+// The comparisons all contain "=", as properties are given as name=value.
+var assertionRegexp = regexp.MustCompile(`^\s*(?:([A-Za-z0-9_]+)\.)?([a-z0-9_]+)\s*(<=|>=|==|!=)\s*(.+?)\s*$`)
+
+// assertionCmps are the comparisons of the assertions.
+var assertionCmps = []string{"<=", ">=", "==", "!="}
+
+// runStats are the stats of assertions without an operation.
+var runStats = map[string]bool{"errors": true, "timeouts": true}
+
+// operationStats are the stats of assertions on an operation.
+var operationStats = map[string]bool{
+	"count": true, "errors": true, "timeouts": true, "error_rate": true, "ops": true, "ops_per_sec": true,
+	"avg_us": true, "min_us": true, "max_us": true,
+	"p50_us": true, "p90_us": true, "p95_us": true, "p99_us": true, "p999_us": true, "p9999_us": true,
+}
+
+// ParseAssertions returns the assertions of the assert.* properties. A property
+// like assert.READ.p99_us<=5000 is split as "assert.READ.p99_us<" = "5000", so
+// the assertion is the name and the value joined back with "=". With spaces,
+// as in assert.READ.p99_us <= 5000, a property file splits it at the first
+// space instead and the value starts with the comparison.
+func ParseAssertions(p *properties.Properties) ([]Assertion, error) {
+	var assertions []Assertion
+	for _, key := range p.Keys() {
+		if !strings.HasPrefix(key, prop.AssertPrefix) {
+			continue
+		}
+		name, value := strings.TrimPrefix(key, prop.AssertPrefix), p.GetString(key, "")
+		expr := name + "=" + value
+		for _, cmp := range assertionCmps {
+			if strings.HasPrefix(strings.TrimSpace(value), cmp) {
+				expr = name + value
+				break
+			}
+		}
+		m := assertionRegexp.FindStringSubmatch(expr)
+		if m == nil {
+			return nil, fmt.Errorf("invalid assertion %s, want [<OP>.]<stat><=|>=|==|!=<value>", expr)
+		}
+		a := Assertion{Operation: m[1], Stat: m[2], Cmp: m[3]}
+		a.Expr = a.Stat + a.Cmp + m[4]
+		if a.Operation != "" {
+			a.Expr = a.Operation + "." + a.Expr
+		}
+		expr = a.Expr
+		if a.Operation == "" && !runStats[a.Stat] {
+			return nil, fmt.Errorf("invalid assertion %s, %s needs an operation", expr, a.Stat)
+		}
+		if a.Operation != "" && !operationStats[a.Stat] {
+			return nil, fmt.Errorf("invalid assertion %s, unknown stat %s", expr, a.Stat)
+		}
+		v, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %s: %v", expr, err)
+		}
+		a.Value = v
+		assertions = append(assertions, a)
+	}
+	sort.Slice(assertions, func(i, j int) bool { return assertions[i].Expr < assertions[j].Expr })
+	return assertions, nil
+}
+
+// Stat returns the named stat of the operation, named like its JSON field
+// with ops as a shorthand for ops_per_sec, and error_rate the share of failed
+// and timed out operations. Stats that are not counts are not measured
+// without a successful operation.
+func (o *OperationResult) Stat(name string) (float64, bool) {
+	failed := o.Errors + o.Timeouts
+	switch name {
+	case "count":
+		return float64(o.Count), true
+	case "errors":
+		return float64(o.Errors), true
+	case "timeouts":
+		return float64(o.Timeouts), true
+	case "error_rate":
+		if o.Count+failed == 0 {
+			return 0, false
+		}
+		return float64(failed) / float64(o.Count+failed), true
+	}
+
+	var v float64
+	switch name {
+	case "ops", "ops_per_sec":
+		v = o.OpsPerSec
+	case "avg_us":
+		v = o.AvgUs
+	case "min_us":
+		v = float64(o.MinUs)
+	case "max_us":
+		v = float64(o.MaxUs)
+	case "p50_us":
+		v = float64(o.P50Us)
+	case "p90_us":
+		v = float64(o.P90Us)
+	case "p95_us":
+		v = float64(o.P95Us)
+	case "p99_us":
+		v = float64(o.P99Us)
+	case "p999_us":
+		v = float64(o.P999Us)
+	case "p9999_us":
+		v = float64(o.P9999Us)
+	default:
+		return 0, false
+	}
+	return v, o.Count > 0
+}
+
+func (a Assertion) actual(r *Result) (float64, bool) {
+	if a.Operation == "" {
+		var sum int64
+		for _, o := range r.Operations {
+			if a.Stat == "errors" {
+				sum += o.Errors
+			} else {
+				sum += o.Timeouts
+			}
+		}
+		return float64(sum), true
+	}
+	o := r.OperationStats(a.Operation)
+	if o == nil {
+		// nothing measured, the counts are 0
+		o = &OperationResult{Name: a.Operation}
+	}
+	return o.Stat(a.Stat)
+}
+
+// Check evaluates the assertion against the results of a run.
+func (a Assertion) Check(r *Result) AssertionResult {
+	res := AssertionResult{Assertion: a.Expr}
+	actual, ok := a.actual(r)
+	if !ok {
+		return res
+	}
+	res.Actual = &actual
+	switch a.Cmp {
+	case "<=":
+		res.Passed = actual <= a.Value
+	case ">=":
+		res.Passed = actual >= a.Value
+	case "==":
+		res.Passed = actual == a.Value
+	case "!=":
+		res.Passed = actual != a.Value
+	}
+	return res
+}
+
+// checkAssertions evaluates the assertions, it returns their results and
+// whether they all passed.
+func checkAssertions(r *Result, assertions []Assertion) ([]AssertionResult, bool) {
+	results := make([]AssertionResult, 0, len(assertions))
+	passed := true
+	for _, a := range assertions {
+		res := a.Check(r)
+		passed = passed && res.Passed
+		results = append(results, res)
+	}
+	return results, passed
+}
+
+// renderAssertions writes one PASS or FAIL line per assertion.
+func renderAssertions(w io.Writer, results []AssertionResult) {
+	for _, res := range results {
+		status := "PASS"
+		if !res.Passed {
+			status = "FAIL"
+		}
+		actual := "not measured"
+		if res.Actual != nil {
+			actual = "actual " + strconv.FormatFloat(*res.Actual, 'f', -1, 64)
+		}
+		fmt.Fprintf(w, "%s %s (%s)\n", status, res.Assertion, actual)
+	}
+}
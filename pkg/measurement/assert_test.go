@@ -0,0 +1,102 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties"
+)
+
+func TestParseAssertions(t *testing.T) {
+	// as read from a property file, "=" separates the name from the value
+	p := properties.MustLoadString("assert.READ.p99_us<=5000\nassert.TOTAL.ops>=20000\nassert.errors==0\nassert.UPDATE.count!=0\nlabel=x\n")
+	assertions, err := ParseAssertions(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Assertion{
+		{"READ.p99_us<=5000", "READ", "p99_us", "<=", 5000},
+		{"TOTAL.ops>=20000", "TOTAL", "ops", ">=", 20000},
+		{"UPDATE.count!=0", "UPDATE", "count", "!=", 0},
+		{"errors==0", "", "errors", "==", 0},
+	}
+	if len(assertions) != len(want) {
+		t.Fatalf("got %+v, want %+v", assertions, want)
+	}
+	for i := range want {
+		if assertions[i] != want[i] {
+			t.Errorf("got %+v, want %+v", assertions[i], want[i])
+		}
+	}
+
+	// spaces around the comparison, in a property file or given as name=value
+	spaced := properties.MustLoadString("assert.READ.p99_us <= 5000\nassert.TOTAL.ops >=20000\n")
+	spaced.Set("assert.errors =", " 0 ")
+	spaced.Set("assert.UPDATE.count !", " 0")
+	if assertions, err = ParseAssertions(spaced); err != nil {
+		t.Fatal(err)
+	}
+	if len(assertions) != len(want) {
+		t.Fatalf("got %+v with spaces, want %+v", assertions, want)
+	}
+	for i := range want {
+		if assertions[i] != want[i] {
+			t.Errorf("got %+v with spaces, want %+v", assertions[i], want[i])
+		}
+	}
+
+	invalid := []string{
+		"assert.READ.p99_us=5000",
+		"assert.READ.p42_us<=5000",
+		"assert.p99_us<=5000",
+		"assert.READ.count>=many",
+	}
+	for _, line := range invalid {
+		if _, err := ParseAssertions(properties.MustLoadString(line)); err == nil {
+			t.Errorf("%s: got no error", line)
+		}
+	}
+}
+
+func TestCheckAssertions(t *testing.T) {
+	r := &Result{Operations: []OperationResult{
+		{Name: "READ", Count: 90, Errors: 10, P99Us: 4000, OpsPerSec: 1000, Intended: &LatencyResult{Count: 90, P99Us: 6000}},
+		{Name: "UPDATE", Timeouts: 2},
+	}}
+	tests := []struct {
+		assertion Assertion
+		passed    bool
+		measured  bool
+	}{
+		{Assertion{Operation: "READ", Stat: "p99_us", Cmp: "<=", Value: 5000}, true, true},
+		{Assertion{Operation: "READ", Stat: "ops", Cmp: ">=", Value: 2000}, false, true},
+		{Assertion{Operation: "READ", Stat: "error_rate", Cmp: "<=", Value: 0.1}, true, true},
+		{Assertion{Operation: "INTENDED_READ", Stat: "p99_us", Cmp: "<=", Value: 5000}, false, true},
+		{Assertion{Operation: "UPDATE", Stat: "p99_us", Cmp: "<=", Value: 5000}, false, false},
+		{Assertion{Operation: "INSERT", Stat: "count", Cmp: "==", Value: 0}, true, true},
+		{Assertion{Stat: "errors", Cmp: "==", Value: 10}, true, true},
+		{Assertion{Stat: "timeouts", Cmp: "==", Value: 0}, false, true},
+	}
+	for _, test := range tests {
+		res := test.assertion.Check(r)
+		if res.Passed != test.passed || (res.Actual != nil) != test.measured {
+			t.Errorf("%+v: got %+v, want passed %v measured %v", test.assertion, res, test.passed, test.measured)
+		}
+	}
+
+	if _, passed := checkAssertions(r, []Assertion{tests[0].assertion, tests[1].assertion}); passed {
+		t.Errorf("got all assertions passed, want a failure")
+	}
+}
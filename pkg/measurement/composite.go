@@ -0,0 +1,223 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/ycsb"
+)
+
+// sink is a measurer and the file its output is written to, stdout if empty.
+type sink struct {
+	name     string
+	measurer ycsb.Measurer
+	outFile  string
+}
+
+// composite fans out every measurement to several measurers.
+type composite struct {
+	sinks []sink
+	// mergers take the histograms of the shards, streams take every
+	// measurement of the shards and must be safe for concurrent use.
+	mergers []histogramMerger
+	streams []ycsb.Measurer
+}
+
+// histogramMerger is implemented by the measurers that can record the latency
+// counts of a shard in one go, op having started at start at the earliest.
+type histogramMerger interface {
+	merge(op string, start time.Time, counts *latencyCounts)
+}
+
+// outputFiles maps the measurement types to the property of their output file.
+var outputFiles = map[string]string{
+	"histogram": prop.MeasurementHistogramOutputFile,
+	"raw":       prop.MeasurementCSVOutputFile,
+	"csv":       prop.MeasurementCSVOutputFile,
+	"hdrlog":    prop.MeasurementHdrLogOutputFile,
+}
+
+// phaseFileProperties are the properties naming output files.
+var phaseFileProperties = []string{
+	prop.MeasurementRawOutputFile,
+	prop.MeasurementHistogramOutputFile,
+	prop.MeasurementCSVOutputFile,
+	prop.MeasurementHdrLogOutputFile,
+	prop.MeasurementResultFile,
+}
+
+// SetOutputPhase adds the phase name to the output files set in p, eg:
+// result.json becomes result.load.json, so that the phases of a scenario or the
+// steps of a sweep don't overwrite each other's files.
+func SetOutputPhase(p *properties.Properties, phase string) {
+	for _, key := range phaseFileProperties {
+		if name := p.GetString(key, ""); name != "" {
+			p.Set(key, phaseFileName(name, phase))
+		}
+	}
+}
+
+// phaseFileName inserts the phase before the extensions, eg: raw.csv.gz becomes
+// raw.load.csv.gz.
+func phaseFileName(name string, phase string) string {
+	dir, base := filepath.Split(name)
+	ext := ""
+	if i := strings.Index(base, "."); i > 0 {
+		base, ext = base[:i], base[i:]
+	}
+	return dir + base + "." + phase + ext
+}
+
+// newComposite creates the measurers of a comma separated list of measurement
+// types, eg: "histogram,raw,hdrlog". Each one writes to its own output file,
+// measurement.output_file unless overridden, and two of them can't share one.
+// raw without histogram gets a histogram printing to stdout.
+func newComposite(p *properties.Properties, measurementTypes string) (*composite, error) {
+	c := new(composite)
+	types := make(map[string]string)
+	files := make(map[string]string)
+	// check the whole list before creating measurers, raw starts writing at once
+	for _, name := range strings.Split(measurementTypes, ",") {
+		name = strings.TrimSpace(name)
+		fileProp, ok := outputFiles[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported measurement type: %s", name)
+		}
+		if other, ok := types[fileProp]; ok {
+			return nil, fmt.Errorf("measurement types %s and %s are both listed", other, name)
+		}
+		types[fileProp] = name
+		outFile := p.GetString(fileProp, p.GetString(prop.MeasurementRawOutputFile, ""))
+		if outFile != "" {
+			if other, ok := files[outFile]; ok {
+				return nil, fmt.Errorf("measurement types %s and %s both write to %s, set %s", other, name, outFile, fileProp)
+			}
+			files[outFile] = name
+		}
+		c.sinks = append(c.sinks, sink{name: name, outFile: outFile})
+	}
+	// raw only streams the measurements, a histogram keeps the summaries and
+	// the results on stdout
+	_, raw := types[prop.MeasurementCSVOutputFile]
+	if _, ok := types[prop.MeasurementHistogramOutputFile]; raw && !ok {
+		c.sinks = append(c.sinks, sink{name: "histogram"})
+	}
+
+	for i, s := range c.sinks {
+		switch s.name {
+		case "histogram":
+			c.sinks[i].measurer = InitHistograms(p)
+		case "raw", "csv":
+			// the raw measurements are streamed to the output file
+			c.sinks[i].measurer = InitCSV(p, s.outFile)
+			c.sinks[i].outFile = ""
+		case "hdrlog":
+			// the intervals are written to the output file as they end
+			c.sinks[i].measurer = InitHdrLog(s.outFile)
+			c.sinks[i].outFile = ""
+		}
+		if merger, ok := c.sinks[i].measurer.(histogramMerger); ok {
+			c.mergers = append(c.mergers, merger)
+		} else {
+			c.streams = append(c.streams, c.sinks[i].measurer)
+		}
+	}
+	return c, nil
+}
+
+func (c *composite) Measure(op string, start time.Time, lan time.Duration) {
+	for _, s := range c.sinks {
+		s.measurer.Measure(op, start, lan)
+	}
+}
+
+// merge records the latency counts of a shard into the measurers taking
+// histograms.
+func (c *composite) merge(op string, start time.Time, counts *latencyCounts) {
+	for _, m := range c.mergers {
+		m.merge(op, start, counts)
+	}
+}
+
+// stream records a measurement of a shard into the other measurers.
+func (c *composite) stream(op string, start time.Time, lan time.Duration) {
+	for _, m := range c.streams {
+		m.Measure(op, start, lan)
+	}
+}
+
+func (c *composite) Summary() {
+	for _, s := range c.sinks {
+		s.measurer.Summary()
+	}
+}
+
+func (c *composite) GenerateExtendedOutputs() {
+	for _, s := range c.sinks {
+		s.measurer.GenerateExtendedOutputs()
+	}
+}
+
+// Output writes the output of the measurers without an output file to w, and
+// the others to their file.
+func (c *composite) Output(w io.Writer) error {
+	for _, s := range c.sinks {
+		if s.outFile == "" {
+			if err := s.measurer.Output(w); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := outputFile(s.measurer, s.outFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func outputFile(m ycsb.Measurer, name string) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := m.Output(w); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// histograms returns the histogram measurer, or nil if none is listed.
+func (c *composite) histograms() *histograms {
+	for _, s := range c.sinks {
+		if h, ok := s.measurer.(*histograms); ok {
+			return h
+		}
+	}
+	return nil
+}
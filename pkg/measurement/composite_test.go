@@ -0,0 +1,137 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+func TestComposite(t *testing.T) {
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "raw.csv")
+	hlog := filepath.Join(dir, "run.hlog")
+	p := properties.LoadMap(map[string]string{
+		prop.MeasurementCSVOutputFile:    raw,
+		prop.MeasurementHdrLogOutputFile: hlog,
+	})
+	c, err := newComposite(p, "histogram, raw,hdrlog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		c.Measure("READ", time.Now(), time.Millisecond)
+	}
+	if h := c.histograms(); h == nil || h.histograms["READ"].hist.TotalCount() != 10 {
+		t.Errorf("got histograms %v, want 10 READ", h)
+	}
+
+	var out bytes.Buffer
+	if err := c.Output(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "READ   - Takes(s)") {
+		t.Errorf("got output %q, want the READ histogram", out.String())
+	}
+	if lines := readRaw(t, raw, false); len(lines) != 11 {
+		t.Errorf("got %d raw lines, want the header and 10 rows", len(lines))
+	}
+	f, err := os.Open(hlog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	hist, err := hdrhistogram.NewHistogramLogReader(f).NextIntervalHistogram()
+	if err != nil || hist == nil || hist.TotalCount() != 10 {
+		t.Errorf("got interval histogram %v, %v, want 10 READ", hist, err)
+	}
+}
+
+func TestCompositeRawSummary(t *testing.T) {
+	p := properties.LoadMap(map[string]string{
+		prop.MeasurementCSVOutputFile: filepath.Join(t.TempDir(), "raw.csv"),
+	})
+	c, err := newComposite(p, "raw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Measure("READ", time.Now(), time.Millisecond)
+	if h := c.histograms(); h == nil || h.histograms["READ"].hist.TotalCount() != 1 {
+		t.Fatalf("got histograms %v, want one added for the summaries", h)
+	}
+
+	var out bytes.Buffer
+	if err := c.Output(&out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "READ   - Takes(s)") {
+		t.Errorf("got output %q, want the READ histogram", out.String())
+	}
+}
+
+func TestCompositeInvalid(t *testing.T) {
+	tests := []struct {
+		types string
+		props map[string]string
+	}{
+		{"histogram,unknown", nil},
+		{"raw,csv", nil},
+		{"histogram,hdrlog", map[string]string{prop.MeasurementRawOutputFile: "out.txt"}},
+		{"raw,hdrlog", map[string]string{prop.MeasurementCSVOutputFile: "out.txt", prop.MeasurementHdrLogOutputFile: "out.txt"}},
+	}
+	for _, test := range tests {
+		if _, err := newComposite(properties.LoadMap(test.props), test.types); err == nil {
+			t.Errorf("newComposite(%s, %v) succeeded, want an error", test.types, test.props)
+		}
+	}
+}
+
+func TestSetOutputPhase(t *testing.T) {
+	dir := t.TempDir()
+	result := filepath.Join(dir, "result.json")
+	for _, phase := range []string{"load", "run"} {
+		p := properties.LoadMap(map[string]string{
+			prop.MeasurementResultFile:    result,
+			prop.MeasurementCSVOutputFile: filepath.Join(dir, "raw.csv.gz"),
+			prop.MeasurementType:          "histogram,raw",
+			prop.Label:                    phase,
+		})
+		SetOutputPhase(p, phase)
+		if got, want := p.GetString(prop.MeasurementCSVOutputFile, ""), filepath.Join(dir, "raw."+phase+".csv.gz"); got != want {
+			t.Errorf("got raw file %s, want %s", got, want)
+		}
+		InitMeasure(p)
+		Measure("READ", time.Now(), time.Millisecond)
+		Output()
+	}
+
+	// both phases kept their result
+	for _, phase := range []string{"load", "run"} {
+		r, err := ReadResult(filepath.Join(dir, "result."+phase+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.Label != phase {
+			t.Errorf("got label %s in the %s result", r.Label, phase)
+		}
+	}
+}
@@ -1,54 +1,101 @@
 package measurement
 
 import (
-	"fmt"
 	"io"
+	"sync"
 	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 )
 
+// csvQueueSize is the number of entries buffered for the background writer.
+const csvQueueSize = 16 * 1024
+
 type csventry struct {
+	op string
 	// start time of the operation in us from unix epoch
 	startUs int64
 	// latency of the operation in us
 	latencyUs int64
 }
 
+// csvs streams every measurement to the output file through a background
+// writer.
 type csvs struct {
-	opCsv map[string][]csventry
+	// mu guards closed, the measurements are dropped once entries is closed.
+	mu        sync.RWMutex
+	closed    bool
+	closeOnce sync.Once
+	entries   chan csventry
+	done      chan struct{}
+	// err is the first write error, it is set by the writer before done is closed.
+	err error
 }
 
 func (c *csvs) GenerateExtendedOutputs() {
 }
 
-func InitCSV() *csvs {
-	return &csvs{
-		opCsv: make(map[string][]csventry),
+func InitCSV(p *properties.Properties, outFile string) *csvs {
+	w, err := newRawWriter(
+		outFile,
+		p.GetBool(prop.MeasurementRawGzip, false),
+		p.GetInt64(prop.MeasurementRawRotateMB, 0)*1024*1024,
+	)
+	if err != nil {
+		panic("failed to create output file: " + err.Error())
+	}
+
+	c := &csvs{
+		entries: make(chan csventry, csvQueueSize),
+		done:    make(chan struct{}),
+	}
+	go c.write(w)
+	return c
+}
+
+func (c *csvs) write(w *rawWriter) {
+	defer close(c.done)
+
+	for entry := range c.entries {
+		// keep draining the entries after an error so that Measure never blocks
+		if c.err == nil {
+			c.err = w.write(entry)
+		}
+	}
+	if err := w.close(); c.err == nil {
+		c.err = err
 	}
 }
 
 func (c *csvs) Measure(op string, start time.Time, lan time.Duration) {
-	c.opCsv[op] = append(c.opCsv[op], csventry{
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.closed {
+		return
+	}
+	c.entries <- csventry{
+		op:        op,
 		startUs:   start.UnixMicro(),
 		latencyUs: lan.Microseconds(),
-	})
+	}
 }
 
+// Output waits for all entries to be written, the entries were streamed to the
+// output file so nothing is written to w. The measurements after the first
+// Output are dropped.
 func (c *csvs) Output(w io.Writer) error {
-	_, err := fmt.Fprintln(w, "operation,timestamp_us,latency_us")
-	if err != nil {
-		return err
-	}
-	for op, entries := range c.opCsv {
-		for _, entry := range entries {
-			_, err := fmt.Fprintf(w, "%s,%d,%d\n", op, entry.startUs, entry.latencyUs)
-			if err != nil {
-				return err
-			}
-		}
-	}
-	return nil
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		c.closed = true
+		close(c.entries)
+		c.mu.Unlock()
+	})
+	<-c.done
+	return c.err
 }
 
+// Summary does nothing, the summaries come from the histogram measurer listed
+// with raw.
 func (c *csvs) Summary() {
-	// do nothing as csvs don't keep a summary
 }
@@ -0,0 +1,137 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// readRaw returns the lines of a raw measurement file.
+func readRaw(t *testing.T, name string, gz bool) []string {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gz {
+		if r, err = gzip.NewReader(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func TestRotatedName(t *testing.T) {
+	tests := []struct {
+		name  string
+		index int
+		want  string
+	}{
+		{"raw.csv", 0, "raw.csv"},
+		{"raw.csv.gz", 2, "raw-2.csv.gz"},
+		{"/tmp/out.d/raw", 1, "/tmp/out.d/raw-1"},
+	}
+	for _, test := range tests {
+		if got := rotatedName(test.name, test.index); got != test.want {
+			t.Errorf("rotatedName(%s, %d) = %s, want %s", test.name, test.index, got, test.want)
+		}
+	}
+}
+
+func TestRawWriterRotation(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "raw.csv")
+	w, err := newRawWriter(name, false, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const entries = 20
+	for i := 0; i < entries; i++ {
+		// flush every line so that the file size follows the entries
+		if err := w.buf.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.write(csventry{op: "READ", startUs: int64(i), latencyUs: 1000}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var rows int
+	for i := 0; i <= w.index; i++ {
+		lines := readRaw(t, rotatedName(name, i), false)
+		if lines[0]+"\n" != rawHeader {
+			t.Errorf("file %d starts with %q, want the header", i, lines[0])
+		}
+		rows += len(lines) - 1
+	}
+	if w.index == 0 || rows != entries {
+		t.Errorf("got %d rows in %d files, want %d rows in several files", rows, w.index+1, entries)
+	}
+}
+
+func TestCSVStreaming(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "raw.csv.gz")
+	p := properties.LoadMap(map[string]string{
+		prop.MeasurementRawGzip: "true",
+	})
+	c := InitCSV(p, name)
+
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		c.Measure("READ", start, time.Millisecond)
+	}
+	c.Measure("UPDATE", start, 2*time.Millisecond)
+
+	var out bytes.Buffer
+	if err := c.Output(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("got output %q, want nothing", out.String())
+	}
+
+	// measurements and outputs after the first Output are dropped
+	c.Measure("READ", start, time.Millisecond)
+	if err := c.Output(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := readRaw(t, name, true)
+	if len(lines) != 1002 {
+		t.Fatalf("got %d lines, want the header and 1001 rows", len(lines))
+	}
+	if want := "UPDATE," + strings.Split(lines[1], ",")[1] + ",2000"; lines[1001] != want {
+		t.Errorf("got last row %q, want %q", lines[1001], want)
+	}
+}
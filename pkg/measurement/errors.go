@@ -0,0 +1,136 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/go-ycsb/pkg/prop"
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+const (
+	// errorSamples is the number of distinct messages kept per operation and
+	// error class.
+	errorSamples = 5
+	// errorSampleLen is the length the messages are truncated to.
+	errorSampleLen = 256
+)
+
+// ErrorClass counts the failures of an operation of one class, with samples
+// of their distinct messages.
+type ErrorClass struct {
+	Class   string   `json:"class"`
+	Count   int64    `json:"count"`
+	Samples []string `json:"samples"`
+}
+
+// errorClasses breaks the failed operations down by class. Failures are rare
+// compared to measurements, so they go through one lock rather than shards.
+type errorClasses struct {
+	sync.Mutex
+	// classes maps the operations to their error classes
+	classes map[string]map[string]*ErrorClass
+}
+
+func (e *errorClasses) record(op string, class string, msg string) {
+	if len(msg) > errorSampleLen {
+		msg = strings.ToValidUTF8(msg[:errorSampleLen], "")
+	}
+
+	e.Lock()
+	defer e.Unlock()
+	if e.classes == nil {
+		e.classes = make(map[string]map[string]*ErrorClass)
+	}
+	classes, ok := e.classes[op]
+	if !ok {
+		classes = make(map[string]*ErrorClass)
+		e.classes[op] = classes
+	}
+	c, ok := classes[class]
+	if !ok {
+		c = &ErrorClass{Class: class}
+		classes[class] = c
+	}
+	c.Count++
+	if len(c.Samples) < errorSamples {
+		for _, sample := range c.Samples {
+			if sample == msg {
+				return
+			}
+		}
+		c.Samples = append(c.Samples, msg)
+	}
+}
+
+// snapshot returns a copy of the error classes of each operation, sorted by
+// class.
+func (e *errorClasses) snapshot() map[string][]ErrorClass {
+	e.Lock()
+	defer e.Unlock()
+
+	snapshot := make(map[string][]ErrorClass, len(e.classes))
+	for op, classes := range e.classes {
+		list := make([]ErrorClass, 0, len(classes))
+		for _, c := range classes {
+			list = append(list, ErrorClass{Class: c.Class, Count: c.Count, Samples: append([]string(nil), c.Samples...)})
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Class < list[j].Class })
+		snapshot[op] = list
+	}
+	return snapshot
+}
+
+// render writes the error classes of each operation in the output style,
+// with the message samples or not.
+func (e *errorClasses) render(w io.Writer, outputStyle string, withSamples bool) {
+	snapshot := e.snapshot()
+	ops := make([]string, 0, len(snapshot))
+	for op := range snapshot {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	header := []string{"Operation", "Error", "Count"}
+	if withSamples {
+		header = append(header, "Samples")
+	}
+	lines := [][]string{}
+	for _, op := range ops {
+		for _, c := range snapshot[op] {
+			line := []string{op + "_ERROR", c.Class, util.IntToString(c.Count)}
+			if withSamples {
+				line = append(line, strings.Join(c.Samples, " | "))
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	switch outputStyle {
+	case util.OutputStyleJson:
+		util.RenderJson(w, header, lines)
+	case util.OutputStyleTable:
+		util.RenderTable(w, header, lines)
+	default:
+		util.RenderString(w, "%-6s - %s\n", header, lines)
+	}
+}
+
+func (m *measurement) renderErrors(w io.Writer, withSamples bool) {
+	m.errors.render(w, m.p.GetString(prop.OutputStyle, util.OutputStylePlain), withSamples)
+}
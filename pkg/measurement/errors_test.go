@@ -0,0 +1,54 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/go-ycsb/pkg/util"
+)
+
+func TestErrorClasses(t *testing.T) {
+	var e errorClasses
+	for i := 0; i < 10; i++ {
+		e.record("READ", "fdb_1020", fmt.Sprintf("not committed %d", i%7))
+		e.record("READ", "fdb_1020", "not committed 0")
+	}
+	e.record("READ", "timeout", strings.Repeat("x", 2*errorSampleLen))
+	e.record("UPDATE", "other", "boom")
+
+	snapshot := e.snapshot()
+	read := snapshot["READ"]
+	if len(read) != 2 || read[0].Class != "fdb_1020" || read[1].Class != "timeout" {
+		t.Fatalf("got READ classes %+v, want fdb_1020 and timeout", read)
+	}
+	if read[0].Count != 20 || len(read[0].Samples) != errorSamples {
+		t.Errorf("got %d fdb_1020 with samples %q, want 20 with %d distinct samples", read[0].Count, read[0].Samples, errorSamples)
+	}
+	if len(read[1].Samples[0]) != errorSampleLen {
+		t.Errorf("got a sample of %d bytes, want it truncated to %d", len(read[1].Samples[0]), errorSampleLen)
+	}
+
+	var buf bytes.Buffer
+	e.render(&buf, util.OutputStylePlain, false)
+	want := "READ_ERROR - Error: fdb_1020, Count: 20\n" +
+		"READ_ERROR - Error: timeout, Count: 1\n" +
+		"UPDATE_ERROR - Error: other, Count: 1\n"
+	if buf.String() != want {
+		t.Errorf("got\n%swant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// hdrLogVersion is the version of the HdrHistogram log format written.
+const hdrLogVersion = "1.3"
+
+// hdrlog records one histogram per operation and interval, and outputs them as
+// an HdrHistogram interval log. Every periodic summary ends an interval. The
+// latencies are in microseconds.
+type hdrlog struct {
+	startTime     time.Time
+	intervalStart time.Time
+	interval      map[string]*hdrhistogram.Histogram
+
+	// out gets the log as the intervals end: the output file, or lines when
+	// the log is printed by Output.
+	out   *bufio.Writer
+	file  *os.File
+	lines bytes.Buffer
+	// closed is set by Output, the later measurements are dropped.
+	closed bool
+}
+
+// InitHdrLog creates the hdrlog measurer writing to outFile, or printing the
+// whole log on Output if empty.
+func InitHdrLog(outFile string) *hdrlog {
+	now := time.Now()
+	h := &hdrlog{
+		startTime:     now,
+		intervalStart: now,
+		interval:      make(map[string]*hdrhistogram.Histogram, 16),
+	}
+	if outFile == "" {
+		h.out = bufio.NewWriter(&h.lines)
+	} else {
+		f, err := os.Create(outFile)
+		if err != nil {
+			panic("failed to create output file: " + err.Error())
+		}
+		h.file = f
+		h.out = bufio.NewWriter(f)
+	}
+
+	startSec := float64(h.startTime.UnixMilli()) / 1000
+	fmt.Fprintf(h.out, "#[Histogram log format version %s]\n", hdrLogVersion)
+	fmt.Fprintf(h.out, "#[StartTime: %.3f (seconds since epoch), %s]\n", startSec, h.startTime.Format(time.RFC3339))
+	fmt.Fprintf(h.out, "#[BaseTime: %.3f (seconds since epoch)]\n", startSec)
+	fmt.Fprintf(h.out, "\"StartTimestamp\",\"Interval_Length\",\"Interval_Max\",\"Interval_Compressed_Histogram\"\n")
+	return h
+}
+
+func (h *hdrlog) Measure(op string, start time.Time, lan time.Duration) {
+	hist, ok := h.interval[op]
+	if !ok {
+		hist = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+		h.interval[op] = hist
+	}
+	hist.RecordValue(lan.Microseconds())
+}
+
+func (h *hdrlog) merge(op string, start time.Time, counts *latencyCounts) {
+	interval, ok := h.interval[op]
+	if !ok {
+		interval = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+		h.interval[op] = interval
+	}
+	counts.each(func(us int64, n int64) {
+		interval.RecordValues(us, n)
+	})
+}
+
+// endInterval writes the histograms of the current interval, tagged with
+// their operation, and starts a new interval.
+func (h *hdrlog) endInterval(now time.Time) error {
+	if h.closed {
+		return nil
+	}
+	ops := make([]string, 0, len(h.interval))
+	for op := range h.interval {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	start := h.intervalStart.Sub(h.startTime).Seconds()
+	length := now.Sub(h.intervalStart).Seconds()
+	for _, op := range ops {
+		hist := h.interval[op]
+		if hist.TotalCount() == 0 {
+			continue
+		}
+		payload, err := hist.Encode(hdrhistogram.V2CompressedEncodingCookieBase)
+		if err != nil {
+			return err
+		}
+		// the interval max is in milliseconds
+		fmt.Fprintf(h.out, "Tag=%s,%.3f,%.3f,%.3f,%s\n", op, start, length, float64(hist.Max())/1000, payload)
+		hist.Reset()
+	}
+	h.intervalStart = now
+	return h.out.Flush()
+}
+
+func (h *hdrlog) Summary() {
+	if err := h.endInterval(time.Now()); err != nil {
+		panic("failed to write interval histogram: " + err.Error())
+	}
+}
+
+func (h *hdrlog) GenerateExtendedOutputs() {
+}
+
+// Output ends the last interval and closes the output file, or writes the
+// whole log to w without one.
+func (h *hdrlog) Output(w io.Writer) error {
+	if h.closed {
+		return nil
+	}
+	err := h.endInterval(time.Now())
+	h.closed = true
+	if h.file == nil {
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(h.lines.Bytes())
+		return err
+	}
+	if cerr := h.file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// ReadHdrLog reads an interval log written by the hdrlog measurement type, it
+// returns the interval histograms of each operation in order, with their start
+// and end times in ms since the epoch.
+func ReadHdrLog(r io.Reader) (map[string][]*hdrhistogram.Histogram, error) {
+	reader := hdrhistogram.NewHistogramLogReader(r)
+	intervals := make(map[string][]*hdrhistogram.Histogram)
+	for {
+		hist, err := reader.NextIntervalHistogram()
+		if err != nil {
+			return nil, err
+		}
+		if hist == nil {
+			return intervals, nil
+		}
+		intervals[hist.Tag()] = append(intervals[hist.Tag()], hist)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+)
+
+func TestHdrLog(t *testing.T) {
+	h := InitHdrLog("")
+	for i := 0; i < 10; i++ {
+		h.Measure("READ", time.Now(), time.Millisecond)
+	}
+	h.Measure("UPDATE", time.Now(), 2*time.Millisecond)
+	h.Summary()
+	// the second interval has no UPDATE
+	for i := 0; i < 5; i++ {
+		h.Measure("READ", time.Now(), 3*time.Millisecond)
+	}
+
+	var buf bytes.Buffer
+	if err := h.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		tag   string
+		count int64
+		max   int64
+	}{
+		{"READ", 10, 1000},
+		{"UPDATE", 1, 2000},
+		{"READ", 5, 3000},
+	}
+	r := hdrhistogram.NewHistogramLogReader(&buf)
+	for i, w := range want {
+		hist, err := r.NextIntervalHistogram()
+		if err != nil || hist == nil {
+			t.Fatalf("interval histogram %d: got %v, %v", i, hist, err)
+		}
+		if hist.Tag() != w.tag || hist.TotalCount() != w.count || !hist.ValuesAreEquivalent(hist.Max(), w.max) {
+			t.Errorf("interval histogram %d: got %s count %d max %d, want %s count %d max %d",
+				i, hist.Tag(), hist.TotalCount(), hist.Max(), w.tag, w.count, w.max)
+		}
+	}
+	if hist, err := r.NextIntervalHistogram(); hist != nil || err != nil {
+		t.Errorf("got an extra interval histogram %v, %v", hist, err)
+	}
+}
+
+func TestHdrLogFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "run.hlog")
+	h := InitHdrLog(name)
+	h.Measure("READ", time.Now(), time.Millisecond)
+	h.Summary()
+
+	// the interval is written as soon as it ends
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	hist, err := hdrhistogram.NewHistogramLogReader(f).NextIntervalHistogram()
+	if err != nil || hist == nil || hist.Tag() != "READ" || hist.TotalCount() != 1 {
+		t.Fatalf("got interval histogram %v, %v, want 1 READ", hist, err)
+	}
+
+	var buf bytes.Buffer
+	if err := h.Output(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("got output %q, want the log in the file only", buf.String())
+	}
+}
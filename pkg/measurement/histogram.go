@@ -25,6 +25,11 @@ type histogram struct {
 	boundCounts util.ConcurrentMap
 	startTime   time.Time
 	hist        *hdrhistogram.Histogram
+	// interval has the latencies measured since intervalStart, it is reset by
+	// every periodic summary. newHistogram allocates it, it is only nil for
+	// the histograms given to OutputHistograms, which are not measured.
+	interval      *hdrhistogram.Histogram
+	intervalStart time.Time
 }
 
 // Metric name.
@@ -43,20 +48,38 @@ const (
 	PER9999TH = "PER9999TH"
 )
 
-func newHistogram() *histogram {
+func newHistogram(startTime time.Time) *histogram {
 	h := new(histogram)
-	h.startTime = time.Now()
+	h.startTime = startTime
 	h.hist = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	h.interval = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	h.intervalStart = startTime
 	return h
 }
 
 func (h *histogram) Measure(latency time.Duration) {
 	h.hist.RecordValue(latency.Microseconds())
+	h.interval.RecordValue(latency.Microseconds())
 }
 
 func (h *histogram) Summary() []string {
-	res := h.getInfo()
+	return formatInfo(h.getInfo(h.hist, h.startTime))
+}
+
+// IntervalSummary returns the summary of the latencies measured since the
+// previous call, and starts a new interval.
+func (h *histogram) IntervalSummary() []string {
+	if h.interval == nil {
+		return nil
+	}
+	now := time.Now()
+	res := formatInfo(h.getInfo(h.interval, h.intervalStart))
+	h.interval.Reset()
+	h.intervalStart = now
+	return res
+}
 
+func formatInfo(res map[string]interface{}) []string {
 	return []string{
 		util.FloatToOneString(res[ELAPSED]),
 		util.IntToString(res[COUNT]),
@@ -73,23 +96,23 @@ func (h *histogram) Summary() []string {
 	}
 }
 
-func (h *histogram) getInfo() map[string]interface{} {
-	min := h.hist.Min()
-	max := h.hist.Max()
-	avg := int64(h.hist.Mean())
-	count := h.hist.TotalCount()
+func (h *histogram) getInfo(hist *hdrhistogram.Histogram, startTime time.Time) map[string]interface{} {
+	min := hist.Min()
+	max := hist.Max()
+	avg := int64(hist.Mean())
+	count := hist.TotalCount()
 
 	bounds := h.boundCounts.Keys()
 	sort.Ints(bounds)
 
-	per50 := h.hist.ValueAtPercentile(50)
-	per90 := h.hist.ValueAtPercentile(90)
-	per95 := h.hist.ValueAtPercentile(95)
-	per99 := h.hist.ValueAtPercentile(99)
-	per999 := h.hist.ValueAtPercentile(99.9)
-	per9999 := h.hist.ValueAtPercentile(99.99)
+	per50 := hist.ValueAtPercentile(50)
+	per90 := hist.ValueAtPercentile(90)
+	per95 := hist.ValueAtPercentile(95)
+	per99 := hist.ValueAtPercentile(99)
+	per999 := hist.ValueAtPercentile(99.9)
+	per9999 := hist.ValueAtPercentile(99.99)
 
-	elapsed := time.Now().Sub(h.startTime).Seconds()
+	elapsed := time.Now().Sub(startTime).Seconds()
 	qps := float64(count) / elapsed
 	res := make(map[string]interface{})
 	res[ELAPSED] = elapsed
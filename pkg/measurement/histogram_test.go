@@ -0,0 +1,46 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalSummary(t *testing.T) {
+	h := newHistogram(time.Now())
+	// Summary columns: Takes(s), Count, OPS, Avg, Min, Max, 50th, 90th, 95th, 99th, ...
+	const count, p99 = 1, 9
+
+	for i := 0; i < 10; i++ {
+		h.Measure(time.Millisecond)
+	}
+	if got := h.IntervalSummary(); got[count] != "10" || got[p99] != "1000" {
+		t.Errorf("got first interval count %s p99 %s, want 10 and 1000", got[count], got[p99])
+	}
+
+	// A latency spike shows in its interval even if it is hidden in the totals.
+	for i := 0; i < 5; i++ {
+		h.Measure(100 * time.Millisecond)
+	}
+	if got := h.IntervalSummary(); got[count] != "5" || got[p99] != "100031" {
+		t.Errorf("got second interval count %s p99 %s, want 5 and 100031", got[count], got[p99])
+	}
+	if got := h.IntervalSummary(); got[count] != "0" {
+		t.Errorf("got empty interval count %s, want 0", got[count])
+	}
+	if got := h.Summary(); got[count] != "15" {
+		t.Errorf("got cumulative count %s, want 15", got[count])
+	}
+}
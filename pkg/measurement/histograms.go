@@ -8,6 +8,7 @@ import (
 	"sort"
 	"time"
 
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
 	"github.com/pingcap/go-ycsb/pkg/util"
@@ -44,13 +45,33 @@ func (h *histograms) GenerateExtendedOutputs() {
 func (h *histograms) Measure(op string, start time.Time, lan time.Duration) {
 	opM, ok := h.histograms[op]
 	if !ok {
-		opM = newHistogram()
+		opM = newHistogram(start)
 		h.histograms[op] = opM
 	}
 
 	opM.Measure(lan)
 }
 
+func (h *histograms) merge(op string, start time.Time, counts *latencyCounts) {
+	opM, ok := h.histograms[op]
+	if !ok {
+		opM = newHistogram(start)
+		h.histograms[op] = opM
+	}
+	counts.each(func(us int64, n int64) {
+		opM.hist.RecordValues(us, n)
+		opM.interval.RecordValues(us, n)
+	})
+}
+
+func (h *histograms) snapshot() map[string]*hdrhistogram.Histogram {
+	snapshots := make(map[string]*hdrhistogram.Histogram, len(h.histograms))
+	for op, opM := range h.histograms {
+		snapshots[op] = hdrhistogram.Import(opM.hist.Export())
+	}
+	return snapshots
+}
+
 func (h *histograms) summary() map[string][]string {
 	summaries := make(map[string][]string, len(h.histograms))
 	for op, opM := range h.histograms {
@@ -59,12 +80,31 @@ func (h *histograms) summary() map[string][]string {
 	return summaries
 }
 
+// intervalSummary returns the summaries of the operations measured since the
+// previous periodic summary, labeled "<OP> (interval)".
+func (h *histograms) intervalSummary() map[string][]string {
+	summaries := make(map[string][]string, len(h.histograms))
+	for op, opM := range h.histograms {
+		if summary := opM.IntervalSummary(); summary != nil {
+			summaries[op+" (interval)"] = summary
+		}
+	}
+	return summaries
+}
+
+// Summary prints the cumulative results followed by the ones of the last
+// interval, so that a latency spike is not hidden by a long run.
 func (h *histograms) Summary() {
-	h.Output(os.Stdout)
+	h.render(os.Stdout, h.summary())
+	h.render(os.Stdout, h.intervalSummary())
 }
 
 func (h *histograms) Output(w io.Writer) error {
-	summaries := h.summary()
+	h.render(w, h.summary())
+	return nil
+}
+
+func (h *histograms) render(w io.Writer, summaries map[string][]string) {
 	keys := make([]string, 0, len(summaries))
 	for k := range summaries {
 		keys = append(keys, k)
@@ -89,7 +129,6 @@ func (h *histograms) Output(w io.Writer) error {
 	default:
 		panic("unsupported outputstyle: " + outputStyle)
 	}
-	return nil
 }
 
 func InitHistograms(p *properties.Properties) *histograms {
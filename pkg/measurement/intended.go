@@ -0,0 +1,75 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"context"
+	"time"
+)
+
+// Latency modes of prop.MeasurementLatencyMode.
+const (
+	// LatencyModeOp measures the latency from the actual start of the operation.
+	LatencyModeOp = "op"
+	// LatencyModeIntended measures the latency from the time the throttle scheduled
+	// the operation, so the time spent queued behind a stalled operation is counted.
+	LatencyModeIntended = "intended"
+	// LatencyModeBoth measures both latencies.
+	LatencyModeBoth = "both"
+)
+
+// IntendedPrefix is prepended to the operation name of intended latencies.
+const IntendedPrefix = "INTENDED_"
+
+type intendedStartKey struct{}
+
+// WithIntendedStart returns a context whose operations are measured against the
+// intended start time t points to. The caller updates *t before each operation.
+func WithIntendedStart(ctx context.Context, t *time.Time) context.Context {
+	return context.WithValue(ctx, intendedStartKey{}, t)
+}
+
+// DetachIntendedStart returns a context measured against the current intended
+// start time of ctx, for an operation completing after the caller moved on to
+// the next one.
+func DetachIntendedStart(ctx context.Context) context.Context {
+	t, ok := ctx.Value(intendedStartKey{}).(*time.Time)
+	if !ok {
+		return ctx
+	}
+	detached := *t
+	return WithIntendedStart(ctx, &detached)
+}
+
+func intendedStart(ctx context.Context, start time.Time) time.Time {
+	t, ok := ctx.Value(intendedStartKey{}).(*time.Time)
+	if !ok || t.IsZero() || t.After(start) {
+		return start
+	}
+	return *t
+}
+
+// MeasureOperation measures a successful operation which started at start and
+// took lan. Depending on the latency mode, it records lan under op, the latency
+// from the intended start time in ctx under INTENDED_<op>, or both.
+func MeasureOperation(ctx context.Context, op string, start time.Time, lan time.Duration) {
+	mode := globalMeasure.latencyMode
+	if mode != LatencyModeIntended {
+		MeasureContext(ctx, op, start, lan)
+	}
+	if mode == LatencyModeIntended || mode == LatencyModeBoth {
+		intended := intendedStart(ctx, start)
+		MeasureContext(ctx, IntendedPrefix+op, intended, lan+start.Sub(intended))
+	}
+}
@@ -15,16 +15,22 @@ package measurement
 
 import (
 	"bufio"
+	"fmt"
+	"io"
 	"os"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
 	"github.com/magiconair/properties"
 	"github.com/pingcap/go-ycsb/pkg/prop"
-	"github.com/pingcap/go-ycsb/pkg/ycsb"
 )
 
+// sharedShards is the number of shards shared by the measurements made without
+// a shard of their own.
+const sharedShards = 16
+
 var header = []string{"Operation", "Takes(s)", "Count", "OPS", "Avg(us)", "Min(us)", "Max(us)", "50th(us)", "90th(us)", "95th(us)", "99th(us)", "99.9th(us)", "99.99th(us)"}
 
 type measurement struct {
@@ -32,85 +38,229 @@ type measurement struct {
 
 	p *properties.Properties
 
-	measurer ycsb.Measurer
+	measurer    *composite
+	latencyMode string
+
+	runID     string
+	startTime time.Time
+
+	shardsMu sync.Mutex
+	shards   map[*shard]struct{}
+	// shared are the shards of the measurements made without one, taken in
+	// turn.
+	shared     []*shard
+	nextShared uint32
+
+	errors errorClasses
+
+	assertions       []Assertion
+	assertionsFailed bool
+	// lastResult is the result document of the last Output
+	lastResult *Result
 }
 
-func (m *measurement) measure(op string, start time.Time, lan time.Duration) {
+func (m *measurement) newShard() *shard {
+	s := &shard{m: m, ops: make(map[string]*shardCounts, 16)}
+	m.shardsMu.Lock()
+	m.shards[s] = struct{}{}
+	m.shardsMu.Unlock()
+	return s
+}
+
+// removeShard merges the last measurements of the shard and forgets it.
+func (m *measurement) removeShard(s *shard) {
 	m.Lock()
-	m.measurer.Measure(op, start, lan)
-	m.Unlock()
+	defer m.Unlock()
+
+	s.mergeLocked()
+	m.shardsMu.Lock()
+	delete(m.shards, s)
+	m.shardsMu.Unlock()
 }
 
-func (m *measurement) output() {
-	m.RLock()
-	defer m.RUnlock()
+// mergeShardsLocked merges the measurements of all shards, it must be called
+// with m locked.
+func (m *measurement) mergeShardsLocked() {
+	m.shardsMu.Lock()
+	shards := make([]*shard, 0, len(m.shards))
+	for s := range m.shards {
+		shards = append(shards, s)
+	}
+	m.shardsMu.Unlock()
 
-	outFile := m.p.GetString(prop.MeasurementRawOutputFile, "")
-	var w *bufio.Writer
-	if outFile == "" {
-		w = bufio.NewWriter(os.Stdout)
-	} else {
-		f, err := os.Create(outFile)
-		if err != nil {
-			panic("failed to create output file: " + err.Error())
-		}
-		defer f.Close()
-		w = bufio.NewWriter(f)
+	for _, s := range shards {
+		s.mergeLocked()
 	}
+}
+
+func (m *measurement) measure(op string, start time.Time, lan time.Duration) {
+	i := atomic.AddUint32(&m.nextShared, 1) % uint32(len(m.shared))
+	m.shared[i].measure(op, start, lan)
+}
+
+func (m *measurement) output() {
+	// measurers may end their last interval
+	m.Lock()
+	defer m.Unlock()
+
+	m.mergeShardsLocked()
+	m.measurer.GenerateExtendedOutputs()
 
+	// the measurers with an output file write to it
+	w := bufio.NewWriter(os.Stdout)
 	err := globalMeasure.measurer.Output(w)
 	if err != nil {
 		panic("failed to write output: " + err.Error())
 	}
+	m.renderErrors(w, true)
+
+	r := m.result(time.Now())
+	m.lastResult = r
+	if len(m.assertions) > 0 {
+		var passed bool
+		r.Assertions, passed = checkAssertions(r, m.assertions)
+		m.assertionsFailed = !passed
+		fmt.Fprintln(w, "***************** assertions *****************")
+		renderAssertions(w, r.Assertions)
+	}
 
 	err = w.Flush()
 	if err != nil {
 		panic("failed to flush output: " + err.Error())
 	}
+
+	if resultFile := m.p.GetString(prop.MeasurementResultFile, ""); resultFile != "" {
+		if err := writeResult(resultFile, r); err != nil {
+			panic("failed to write result: " + err.Error())
+		}
+	}
+}
+
+func (m *measurement) histograms() map[string]*hdrhistogram.Histogram {
+	m.Lock()
+	defer m.Unlock()
+
+	m.mergeShardsLocked()
+
+	if h := m.measurer.histograms(); h != nil {
+		return h.snapshot()
+	}
+	return nil
 }
 
 func (m *measurement) summary() {
-	m.RLock()
+	// the summary starts a new interval
+	m.Lock()
+	m.mergeShardsLocked()
 	globalMeasure.measurer.Summary()
-	m.RUnlock()
+	m.Unlock()
+	m.renderErrors(os.Stdout, false)
 }
 
 // InitMeasure initializes the global measurement.
 func InitMeasure(p *properties.Properties) {
 	globalMeasure = new(measurement)
 	globalMeasure.p = p
-	measurementType := p.GetString(prop.MeasurementType, prop.MeasurementTypeDefault)
-	switch measurementType {
-	case "histogram":
-		globalMeasure.measurer = InitHistograms(p)
-	case "raw", "csv":
-		globalMeasure.measurer = InitCSV()
+	globalMeasure.shards = make(map[*shard]struct{})
+	globalMeasure.shared = make([]*shard, sharedShards)
+	for i := range globalMeasure.shared {
+		globalMeasure.shared[i] = globalMeasure.newShard()
+	}
+	globalMeasure.startTime = time.Now()
+	globalMeasure.runID = newRunID(globalMeasure.startTime)
+	measurer, err := newComposite(p, p.GetString(prop.MeasurementType, prop.MeasurementTypeDefault))
+	if err != nil {
+		panic(err.Error())
+	}
+	globalMeasure.measurer = measurer
+	if globalMeasure.assertions, err = ParseAssertions(p); err != nil {
+		panic(err.Error())
+	}
+	globalMeasure.latencyMode = p.GetString(prop.MeasurementLatencyMode, prop.MeasurementLatencyModeDefault)
+	switch globalMeasure.latencyMode {
+	case LatencyModeOp, LatencyModeIntended, LatencyModeBoth:
 	default:
-		panic("unsupported measurement type: " + measurementType)
+		panic("unsupported measurement latency mode: " + globalMeasure.latencyMode)
 	}
 	EnableWarmUp(p.GetInt64(prop.WarmUpTime, 0) > 0)
 }
 
 // Output prints the complete measurements.
 func Output() {
-	globalMeasure.measurer.GenerateExtendedOutputs()
 	globalMeasure.output()
 }
 
+// AssertionsPassed returns false if an assertion failed in the last Output.
+func AssertionsPassed() bool {
+	return !globalMeasure.assertionsFailed
+}
+
+// LastResult returns the result document of the last Output, or nil.
+func LastResult() *Result {
+	return globalMeasure.lastResult
+}
+
 // Summary prints the measurement summary.
 func Summary() {
 	globalMeasure.summary()
 }
 
+// Histograms returns a copy of the latency histogram (in us) of each operation,
+// or nil if the measurement type doesn't keep histograms.
+func Histograms() map[string]*hdrhistogram.Histogram {
+	return globalMeasure.histograms()
+}
+
+// HasHistograms returns whether the measurement types keep the histograms
+// returned by Histograms.
+func HasHistograms() bool {
+	return globalMeasure.measurer.histograms() != nil
+}
+
+// ErrorClasses returns the error classes of each failed operation.
+func ErrorClasses() map[string][]ErrorClass {
+	return globalMeasure.errors.snapshot()
+}
+
+// OutputHistograms writes the summary of the given histograms (in us) like
+// the histogram measurement does, the throughput being computed over elapsed.
+func OutputHistograms(w io.Writer, p *properties.Properties, hists map[string]*hdrhistogram.Histogram, elapsed time.Duration) error {
+	h := InitHistograms(p)
+	start := time.Now().Add(-elapsed)
+	for op, hist := range hists {
+		h.histograms[op] = &histogram{startTime: start, hist: hist}
+	}
+	return h.Output(w)
+}
+
+// HistogramDelta returns the values recorded into cur since prev, an earlier
+// copy of the same histogram, was taken. prev may be nil.
+func HistogramDelta(cur, prev *hdrhistogram.Histogram) *hdrhistogram.Histogram {
+	s := cur.Export()
+	if prev != nil {
+		for i, count := range prev.Export().Counts {
+			s.Counts[i] -= count
+		}
+	}
+	return hdrhistogram.Import(s)
+}
+
 // EnableWarmUp sets whether to enable warm-up.
 func EnableWarmUp(b bool) {
 	if b {
 		atomic.StoreInt32(&warmUp, 1)
 	} else {
+		atomic.StoreInt64(&measureStart, time.Now().UnixNano())
 		atomic.StoreInt32(&warmUp, 0)
 	}
 }
 
+// MeasureStart returns when the operations started being measured, the last
+// time warm-up was disabled.
+func MeasureStart() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&measureStart))
+}
+
 // IsWarmUpFinished returns whether warm-up is finished or not.
 func IsWarmUpFinished() bool {
 	return atomic.LoadInt32(&warmUp) == 0
@@ -123,5 +273,16 @@ func Measure(op string, start time.Time, lan time.Duration) {
 	}
 }
 
+// MeasureError counts a failure of the operation under its error class and
+// keeps a few samples of the messages. The latency is measured separately.
+func MeasureError(op string, class string, err error) {
+	if IsWarmUpFinished() {
+		globalMeasure.errors.record(op, class, err.Error())
+	}
+}
+
 var globalMeasure *measurement
 var warmUp int32 // use as bool, 1 means in warmup progress, 0 means warmup finished.
+
+// measureStart is when warm-up was last disabled, in unix nanoseconds.
+var measureStart int64
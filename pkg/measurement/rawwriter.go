@@ -0,0 +1,125 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const rawHeader = "operation,timestamp_us,latency_us\n"
+
+// countingWriter counts the bytes written to the file.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// rawWriter writes raw measurements to a file, optionally gzipped, and starts
+// a new file once maxSize bytes were written. Without a file name it writes to
+// stdout, never rotated nor compressed.
+type rawWriter struct {
+	name    string
+	gzip    bool
+	maxSize int64
+
+	index int
+	file  *os.File
+	count *countingWriter
+	gz    *gzip.Writer
+	buf   *bufio.Writer
+}
+
+func newRawWriter(name string, gz bool, maxSize int64) (*rawWriter, error) {
+	w := &rawWriter{name: name, gzip: gz, maxSize: maxSize}
+	if name == "" {
+		w.buf = bufio.NewWriter(os.Stdout)
+		_, err := w.buf.WriteString(rawHeader)
+		return w, err
+	}
+	return w, w.open()
+}
+
+// rotatedName returns the name of the index-th file, eg: raw.csv.gz, then
+// raw-1.csv.gz, raw-2.csv.gz...
+func rotatedName(name string, index int) string {
+	if index == 0 {
+		return name
+	}
+	dir, base := filepath.Split(name)
+	ext := ""
+	if i := strings.Index(base, "."); i > 0 {
+		base, ext = base[:i], base[i:]
+	}
+	return fmt.Sprintf("%s%s-%d%s", dir, base, index, ext)
+}
+
+func (w *rawWriter) open() error {
+	f, err := os.Create(rotatedName(w.name, w.index))
+	if err != nil {
+		return err
+	}
+	w.file = f
+	w.count = &countingWriter{w: f}
+	var out io.Writer = w.count
+	if w.gzip {
+		w.gz = gzip.NewWriter(w.count)
+		out = w.gz
+	}
+	w.buf = bufio.NewWriter(out)
+	_, err = w.buf.WriteString(rawHeader)
+	return err
+}
+
+func (w *rawWriter) write(e csventry) error {
+	if _, err := fmt.Fprintf(w.buf, "%s,%d,%d\n", e.op, e.startUs, e.latencyUs); err != nil {
+		return err
+	}
+	// The size is checked on the bytes that reached the file, so files may be
+	// slightly larger than maxSize.
+	if w.file != nil && w.maxSize > 0 && w.count.n >= w.maxSize {
+		if err := w.close(); err != nil {
+			return err
+		}
+		w.index++
+		return w.open()
+	}
+	return nil
+}
+
+func (w *rawWriter) close() error {
+	err := w.buf.Flush()
+	if w.gz != nil {
+		if gzErr := w.gz.Close(); err == nil {
+			err = gzErr
+		}
+	}
+	if w.file != nil {
+		if closeErr := w.file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
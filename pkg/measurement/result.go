@@ -0,0 +1,385 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+// ResultVersion is the version of the result document schema. Fields may be
+// added without changing it, it changes when existing fields change meaning.
+const ResultVersion = 1
+
+// Result describes a run and its measurements, it is written as JSON to
+// measurement.result_file. The operation stats need the histogram measurement
+// type.
+type Result struct {
+	Version        int               `json:"version"`
+	RunID          string            `json:"run_id"`
+	Label          string            `json:"label"`
+	DB             string            `json:"db"`
+	Workload       string            `json:"workload"`
+	Command        string            `json:"command"`
+	Properties     map[string]string `json:"properties"`
+	StartTime      time.Time         `json:"start_time"`
+	EndTime        time.Time         `json:"end_time"`
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	Host           HostInfo          `json:"host"`
+	// Operations are sorted by name, the failures, timeouts, retries and
+	// intended latencies are counted in the operation, not listed.
+	Operations []OperationResult `json:"operations"`
+	// Assertions are the results of the assert.* properties.
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+}
+
+// HostInfo describes the machine and the binary of a run.
+type HostInfo struct {
+	Hostname   string `json:"hostname"`
+	GoVersion  string `json:"go_version"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	NumCPU     int    `json:"num_cpu"`
+	GoMaxProcs int    `json:"gomaxprocs"`
+}
+
+// Kinds of the measurements of an operation, see SplitOperation.
+const (
+	KindOp           = "op"
+	KindIntended     = "intended"
+	KindRetry        = "retry"
+	KindFinalAttempt = "final_attempt"
+	KindError        = "error"
+	KindTimeout      = "timeout"
+)
+
+// kindSuffixes are the suffixes of the measurements of an operation.
+var kindSuffixes = []struct {
+	suffix string
+	kind   string
+}{
+	{"_ERROR", KindError},
+	{"_TIMEOUT", KindTimeout},
+	{"_RETRY", KindRetry},
+	{"_FINAL_ATTEMPT", KindFinalAttempt},
+}
+
+// SplitOperation returns the operation measured under name and the kind of
+// the measurement, eg: READ and KindRetry for READ_RETRY.
+func SplitOperation(name string) (op string, kind string) {
+	if op := strings.TrimPrefix(name, IntendedPrefix); op != name {
+		return op, KindIntended
+	}
+	for _, s := range kindSuffixes {
+		if op := strings.TrimSuffix(name, s.suffix); op != name {
+			return op, s.kind
+		}
+	}
+	return name, KindOp
+}
+
+// LatencyResult has the latency stats of the attempts or the intended
+// latencies of an operation, in us.
+type LatencyResult struct {
+	Count   int64   `json:"count"`
+	AvgUs   float64 `json:"avg_us"`
+	MinUs   int64   `json:"min_us"`
+	MaxUs   int64   `json:"max_us"`
+	P50Us   int64   `json:"p50_us"`
+	P90Us   int64   `json:"p90_us"`
+	P95Us   int64   `json:"p95_us"`
+	P99Us   int64   `json:"p99_us"`
+	P999Us  int64   `json:"p999_us"`
+	P9999Us int64   `json:"p9999_us"`
+}
+
+func newLatencyResult(hist *hdrhistogram.Histogram) *LatencyResult {
+	return &LatencyResult{
+		Count:   hist.TotalCount(),
+		AvgUs:   hist.Mean(),
+		MinUs:   hist.Min(),
+		MaxUs:   hist.Max(),
+		P50Us:   hist.ValueAtPercentile(50),
+		P90Us:   hist.ValueAtPercentile(90),
+		P95Us:   hist.ValueAtPercentile(95),
+		P99Us:   hist.ValueAtPercentile(99),
+		P999Us:  hist.ValueAtPercentile(99.9),
+		P9999Us: hist.ValueAtPercentile(99.99),
+	}
+}
+
+// OperationResult has the stats of an operation, latencies are in us.
+type OperationResult struct {
+	Name           string  `json:"name"`
+	Count          int64   `json:"count"`
+	Errors         int64   `json:"errors"`
+	Timeouts       int64   `json:"timeouts"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	OpsPerSec      float64 `json:"ops_per_sec"`
+	AvgUs          float64 `json:"avg_us"`
+	MinUs          int64   `json:"min_us"`
+	MaxUs          int64   `json:"max_us"`
+	P50Us          int64   `json:"p50_us"`
+	P90Us          int64   `json:"p90_us"`
+	P95Us          int64   `json:"p95_us"`
+	P99Us          int64   `json:"p99_us"`
+	P999Us         int64   `json:"p999_us"`
+	P9999Us        int64   `json:"p9999_us"`
+	// Intended are the latencies from the intended start time, with the
+	// intended or both latency modes.
+	Intended *LatencyResult `json:"intended,omitempty"`
+	// Retries are the latencies of the attempts that were retried, and
+	// FinalAttempts of the last attempt of the operations that succeeded with
+	// retrying enabled.
+	Retries       *LatencyResult `json:"retries,omitempty"`
+	FinalAttempts *LatencyResult `json:"final_attempts,omitempty"`
+	// ErrorClasses break the errors and timeouts down by class.
+	ErrorClasses []ErrorClass `json:"error_classes,omitempty"`
+}
+
+// Operation returns the stats of the named operation, or nil.
+func (r *Result) Operation(name string) *OperationResult {
+	for i := range r.Operations {
+		if r.Operations[i].Name == name {
+			return &r.Operations[i]
+		}
+	}
+	return nil
+}
+
+// OperationStats returns the stats of the named operation like Operation. The
+// intended latencies, retries and final attempts of an operation are named as
+// measured, eg: INTENDED_READ, and only have a count and latencies.
+func (r *Result) OperationStats(name string) *OperationResult {
+	if o := r.Operation(name); o != nil {
+		return o
+	}
+	op, kind := SplitOperation(name)
+	o := r.Operation(op)
+	if o == nil {
+		return nil
+	}
+	var l *LatencyResult
+	switch kind {
+	case KindIntended:
+		l = o.Intended
+	case KindRetry:
+		l = o.Retries
+	case KindFinalAttempt:
+		l = o.FinalAttempts
+	}
+	if l == nil {
+		return nil
+	}
+	return &OperationResult{
+		Name:    name,
+		Count:   l.Count,
+		AvgUs:   l.AvgUs,
+		MinUs:   l.MinUs,
+		MaxUs:   l.MaxUs,
+		P50Us:   l.P50Us,
+		P90Us:   l.P90Us,
+		P95Us:   l.P95Us,
+		P99Us:   l.P99Us,
+		P999Us:  l.P999Us,
+		P9999Us: l.P9999Us,
+	}
+}
+
+// newRunID returns a run ID sorting by start time, eg: 20180102T150405-1a2b3c4d.
+func newRunID(start time.Time) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return start.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(b)
+}
+
+// secretNames are the property name parts whose values are redacted.
+var secretNames = []string{"password", "passwd", "secret", "token", "access_key", "credential"}
+
+const redacted = "REDACTED"
+
+// redactProperties returns the properties with the secrets replaced, both the
+// properties named like one and the passwords of URLs.
+func redactProperties(p *properties.Properties) map[string]string {
+	props := p.Map()
+	for key, value := range props {
+		name := strings.ToLower(key)
+		for _, secret := range secretNames {
+			if strings.Contains(name, secret) {
+				props[key] = redacted
+				break
+			}
+		}
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), redacted)
+				props[key] = u.String()
+			}
+		}
+	}
+	return props
+}
+
+func hostInfo() HostInfo {
+	hostname, _ := os.Hostname()
+	return HostInfo{
+		Hostname:   hostname,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GoMaxProcs: runtime.GOMAXPROCS(0),
+	}
+}
+
+// operationResults returns the stats of the histograms, merging the other
+// measurements of an operation into it.
+func (h *histograms) operationResults() []OperationResult {
+	hists := make(map[string]*hdrhistogram.Histogram, len(h.histograms))
+	for op, opM := range h.histograms {
+		hists[op] = opM.hist
+	}
+	return NewOperationResults(hists, func(op string) time.Duration {
+		return time.Since(h.histograms[op].startTime)
+	})
+}
+
+// NewOperationResults returns the stats of the latency histograms (in us) of
+// each operation, sorted by name. The other measurements of an operation, eg:
+// the errors measured as <OP>_ERROR or the retries as <OP>_RETRY, are counted
+// in it, see SplitOperation. An operation only measured from its intended
+// start, with the intended latency mode, gets its count, throughput and
+// latencies from INTENDED_<OP>. elapsed returns how long an operation was
+// measured for, to compute its throughput.
+func NewOperationResults(hists map[string]*hdrhistogram.Histogram, elapsed func(op string) time.Duration) []OperationResult {
+	byName := make(map[string]*OperationResult, len(hists))
+	// latencies has the name of the histogram of the latencies of every
+	// operation, intended has the name of its intended ones.
+	latencies := make(map[string]string, len(hists))
+	intended := make(map[string]string)
+	for name, hist := range hists {
+		op, kind := SplitOperation(name)
+		r, ok := byName[op]
+		if !ok {
+			r = &OperationResult{Name: op}
+			byName[op] = r
+		}
+		switch kind {
+		case KindError:
+			r.Errors = hist.TotalCount()
+		case KindTimeout:
+			r.Timeouts = hist.TotalCount()
+		case KindIntended:
+			r.Intended = newLatencyResult(hist)
+			intended[op] = name
+		case KindRetry:
+			r.Retries = newLatencyResult(hist)
+		case KindFinalAttempt:
+			r.FinalAttempts = newLatencyResult(hist)
+		default:
+			latencies[op] = name
+		}
+	}
+
+	for op, r := range byName {
+		name, ok := latencies[op]
+		if !ok {
+			if name, ok = intended[op]; !ok {
+				continue
+			}
+		}
+		l := newLatencyResult(hists[name])
+		r.Count = l.Count
+		r.ElapsedSeconds = elapsed(name).Seconds()
+		r.OpsPerSec = float64(r.Count) / r.ElapsedSeconds
+		r.AvgUs, r.MinUs, r.MaxUs = l.AvgUs, l.MinUs, l.MaxUs
+		r.P50Us, r.P90Us, r.P95Us = l.P50Us, l.P90Us, l.P95Us
+		r.P99Us, r.P999Us, r.P9999Us = l.P99Us, l.P999Us, l.P9999Us
+	}
+
+	results := make([]OperationResult, 0, len(byName))
+	for _, r := range byName {
+		results = append(results, *r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results
+}
+
+// result returns the result document of the run ending at end.
+func (m *measurement) result(end time.Time) *Result {
+	r := &Result{
+		Version:        ResultVersion,
+		RunID:          m.runID,
+		Label:          m.p.GetString(prop.Label, ""),
+		DB:             m.p.GetString(prop.DB, ""),
+		Workload:       m.p.GetString(prop.Workload, "core"),
+		Command:        m.p.GetString(prop.Command, ""),
+		Properties:     redactProperties(m.p),
+		StartTime:      m.startTime,
+		EndTime:        end,
+		ElapsedSeconds: end.Sub(m.startTime).Seconds(),
+		Host:           hostInfo(),
+		Operations:     []OperationResult{},
+	}
+	if h := m.measurer.histograms(); h != nil {
+		r.Operations = h.operationResults()
+	}
+	for op, classes := range m.errors.snapshot() {
+		o := r.Operation(op)
+		if o == nil {
+			r.Operations = append(r.Operations, OperationResult{Name: op})
+			o = &r.Operations[len(r.Operations)-1]
+		}
+		o.ErrorClasses = classes
+	}
+	sort.Slice(r.Operations, func(i, j int) bool { return r.Operations[i].Name < r.Operations[j].Name })
+	return r
+}
+
+func writeResult(name string, r *Result) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	// keep the assertions readable
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return err
+	}
+	return os.WriteFile(name, buf.Bytes(), 0644)
+}
+
+// ReadResult reads a result document written to measurement.result_file.
+func ReadResult(name string) (*Result, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	r := new(Result)
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
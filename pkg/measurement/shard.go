@@ -0,0 +1,162 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"context"
+	"math"
+	"math/bits"
+	"sync"
+	"time"
+)
+
+// latencyCounts counts latencies (in us) by the bucket of the measurer
+// histograms, 3 significant digits, they fall in. Unlike a histogram it only
+// takes memory for the buckets measured.
+type latencyCounts struct {
+	counts map[uint32]uint32
+	// overflow has the counts that went past math.MaxUint32.
+	overflow map[uint32]int64
+}
+
+// bucketOf returns the bucket of us: the histograms have 2048 sub-buckets,
+// the larger values keep their 11 most significant bits.
+func bucketOf(us int64) uint32 {
+	shift := 0
+	if n := bits.Len64(uint64(us)); n > 11 {
+		shift = n - 11
+	}
+	return uint32(shift)<<11 | uint32(us>>uint(shift))
+}
+
+// bucketValue returns the lowest value of bucket b.
+func bucketValue(b uint32) int64 {
+	return int64(b&2047) << (b >> 11)
+}
+
+// record counts the latency us.
+func (c *latencyCounts) record(us int64) {
+	if us < 0 {
+		return
+	}
+	if c.counts == nil {
+		c.counts = make(map[uint32]uint32)
+	}
+	b := bucketOf(us)
+	if c.counts[b] == math.MaxUint32 {
+		if c.overflow == nil {
+			c.overflow = make(map[uint32]int64)
+		}
+		c.overflow[b] += math.MaxUint32
+		c.counts[b] = 0
+	}
+	c.counts[b]++
+}
+
+// each calls fn with the lowest value of every bucket counted and its count.
+func (c *latencyCounts) each(fn func(us int64, n int64)) {
+	for b, n := range c.counts {
+		fn(bucketValue(b), int64(n)+c.overflow[b])
+	}
+}
+
+func (c *latencyCounts) empty() bool {
+	return len(c.counts) == 0
+}
+
+// reset forgets the counts, keeping the memory for the next ones.
+func (c *latencyCounts) reset() {
+	for b := range c.counts {
+		delete(c.counts, b)
+	}
+	c.overflow = nil
+}
+
+// shardCounts has the latencies of an operation measured by a shard since
+// its last merge.
+type shardCounts struct {
+	// start is the start of the first operation measured, the measurers count
+	// the throughput of an operation from it.
+	start  time.Time
+	counts latencyCounts
+}
+
+// shard records the measurements of a worker into counts of its own. Its lock
+// is only contended by the lanes of the worker and when the shards are merged,
+// so recording is not slowed down by the other workers.
+type shard struct {
+	sync.Mutex
+
+	m   *measurement
+	ops map[string]*shardCounts
+}
+
+func (s *shard) measure(op string, start time.Time, lan time.Duration) {
+	s.Lock()
+	c, ok := s.ops[op]
+	if !ok {
+		c = &shardCounts{start: start}
+		s.ops[op] = c
+	}
+	c.counts.record(lan.Microseconds())
+	s.Unlock()
+
+	// the raw measurements keep every operation
+	s.m.measurer.stream(op, start, lan)
+}
+
+// mergeLocked merges the counts of the shard into the measurers and resets
+// them, it must be called with s.m locked.
+func (s *shard) mergeLocked() {
+	s.Lock()
+	defer s.Unlock()
+
+	for op, c := range s.ops {
+		if c.counts.empty() {
+			continue
+		}
+		s.m.measurer.merge(op, c.start, &c.counts)
+		c.counts.reset()
+	}
+}
+
+type shardKey struct{}
+
+// WithShard returns a context whose measurements are recorded into a shard of
+// their own, merged into the shared measurers before every report. The shard
+// is merged and released by CleanupShard.
+func WithShard(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shardKey{}, globalMeasure.newShard())
+}
+
+// CleanupShard merges the measurements of the shard of ctx and stops tracking
+// it, the thread using it is done.
+func CleanupShard(ctx context.Context) {
+	if s, ok := ctx.Value(shardKey{}).(*shard); ok {
+		s.m.removeShard(s)
+	}
+}
+
+// MeasureContext measures the latency of op like Measure, recording it into
+// the shard of ctx if it has one.
+func MeasureContext(ctx context.Context, op string, start time.Time, lan time.Duration) {
+	if !IsWarmUpFinished() {
+		return
+	}
+	if s, ok := ctx.Value(shardKey{}).(*shard); ok && s.m == globalMeasure {
+		s.measure(op, start, lan)
+		return
+	}
+	globalMeasure.measure(op, start, lan)
+}
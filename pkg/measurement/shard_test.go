@@ -0,0 +1,146 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package measurement
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/magiconair/properties"
+)
+
+func TestShard(t *testing.T) {
+	InitMeasure(properties.NewProperties())
+
+	const threads, ops = 8, 1000
+	var wg sync.WaitGroup
+	for i := 0; i < threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := WithShard(context.Background())
+			for j := 0; j < ops; j++ {
+				MeasureContext(ctx, "READ", time.Now(), time.Millisecond)
+			}
+			// measurements without a shard go to the shared shards
+			MeasureContext(context.Background(), "READ_ERROR", time.Now(), time.Millisecond)
+		}()
+	}
+	wg.Wait()
+
+	hists := Histograms()
+	if n := hists["READ"].TotalCount(); n != threads*ops {
+		t.Errorf("got %d READ, want %d", n, threads*ops)
+	}
+	if n := hists["READ_ERROR"].TotalCount(); n != threads {
+		t.Errorf("got %d READ_ERROR, want %d", n, threads)
+	}
+	if max := hists["READ"].Max(); !hists["READ"].ValuesAreEquivalent(max, 1000) {
+		t.Errorf("got READ max %dus, want 1000us", max)
+	}
+}
+
+func TestCleanupShard(t *testing.T) {
+	InitMeasure(properties.NewProperties())
+
+	ctx := WithShard(context.Background())
+	MeasureContext(ctx, "READ", time.Now(), time.Millisecond)
+	CleanupShard(ctx)
+
+	if n := len(globalMeasure.shards); n != sharedShards {
+		t.Errorf("got %d shards after the cleanup, want the %d shared ones", n, sharedShards)
+	}
+	if n := Histograms()["READ"].TotalCount(); n != 1 {
+		t.Errorf("got %d READ, want the one measured before the cleanup", n)
+	}
+}
+
+func TestLatencyCounts(t *testing.T) {
+	hist := hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	for _, us := range []int64{0, 1, 1500, 2047, 2048, 2049, 3001, 5000, 123456, 24 * 60 * 60 * 1000 * 1000} {
+		var counts latencyCounts
+		counts.record(us)
+		counts.each(func(v int64, n int64) {
+			if !hist.ValuesAreEquivalent(v, us) || v > us || n != 1 {
+				t.Errorf("%dus counted as %d %dus", us, n, v)
+			}
+		})
+	}
+}
+
+// TestShardMemory checks the memory kept by the shards of the default thread
+// count measuring a workload with a wide latency distribution.
+func TestShardMemory(t *testing.T) {
+	InitMeasure(properties.NewProperties())
+
+	const threads = 200
+	ops := []string{"READ", "UPDATE", "TOTAL", "INTENDED_READ", "INTENDED_UPDATE", "INTENDED_TOTAL"}
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	ctxs := make([]context.Context, threads)
+	for i := range ctxs {
+		ctxs[i] = WithShard(context.Background())
+		for j := 0; j < 1000; j++ {
+			for _, op := range ops {
+				// 1000 buckets from 100us to 100ms
+				MeasureContext(ctxs[i], op, time.Now(), time.Duration(100+j*j/10)*time.Microsecond)
+			}
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	// a histogram of the measurers takes about 229KB
+	const limit = threads * 6 * 32 << 10
+	if n := int64(after.HeapAlloc) - int64(before.HeapAlloc); n > limit {
+		t.Errorf("%d shards keep %d bytes, want at most %d", threads, n, limit)
+	}
+	for _, ctx := range ctxs {
+		CleanupShard(ctx)
+	}
+	if n := Histograms()["READ"].TotalCount(); n != threads*1000 {
+		t.Errorf("got %d READ, want %d", n, threads*1000)
+	}
+}
+
+// BenchmarkMeasure compares 256 threads measuring through the shared shards
+// with the same threads measuring into their own shards.
+func BenchmarkMeasure(b *testing.B) {
+	const threads = 256
+	benchmarks := []struct {
+		name string
+		ctx  func() context.Context
+	}{
+		{"shared", context.Background},
+		{"sharded", func() context.Context { return WithShard(context.Background()) }},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			InitMeasure(properties.NewProperties())
+			b.SetParallelism((threads + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0))
+			b.RunParallel(func(pb *testing.PB) {
+				ctx := bm.ctx()
+				start := time.Now()
+				for pb.Next() {
+					MeasureContext(ctx, "READ", start, time.Millisecond)
+				}
+			})
+		})
+	}
+}
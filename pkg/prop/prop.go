@@ -18,6 +18,9 @@ const (
 	InsertStart        = "insertstart"
 	InsertCount        = "insertcount"
 	InsertStartDefault = int64(0)
+	// TransactionInsertStart is the first key inserted by the transaction
+	// phase, recordcount by default.
+	TransactionInsertStart = "transactioninsertstart"
 
 	OperationCount     = "operationcount"
 	RecordCount        = "recordcount"
@@ -29,14 +32,35 @@ const (
 	ThreadCount        = "threadcount"
 	ThreadCountDefault = int64(200)
 	Target             = "target"
-	MaxExecutiontime   = "maxexecutiontime"
-	WarmUpTime         = "warmuptime"
-	DoTransactions     = "dotransactions"
-	Status             = "status"
-	Label              = "label"
+	// "constant", "ramp", "step", "sine", "file"
+	TargetSchedule        = "target.schedule"
+	TargetScheduleDefault = "constant"
+	TargetScheduleFile    = "target.schedule.file"
+	TargetRampFrom        = "target.ramp.from"
+	TargetRampTo          = "target.ramp.to"
+	TargetRampDuration    = "target.ramp.duration"
+	TargetStepStart       = "target.step.start"
+	TargetStepIncrement   = "target.step.increment"
+	TargetStepInterval    = "target.step.interval"
+	TargetStepMax         = "target.step.max"
+	TargetSineBase        = "target.sine.base"
+	TargetSineAmplitude   = "target.sine.amplitude"
+	TargetSinePeriod      = "target.sine.period"
+	MaxExecutiontime      = "maxexecutiontime"
+	WarmUpTime            = "warmuptime"
+	DoTransactions        = "dotransactions"
+	Seed                  = "seed"
+	Status                = "status"
+	// "text", "json", "none"
+	StatusFormat        = "status.format"
+	StatusFormatDefault = "text"
+	Label               = "label"
 	// batch mode
 	BatchSize        = "batch.size"
 	DefaultBatchSize = int(1)
+	// async mode
+	AsyncDepth        = "async.depth"
+	AsyncDepthDefault = 1
 
 	TableName         = "table"
 	TableNameDefault  = "usertable"
@@ -90,6 +114,31 @@ const (
 	InsertionRetryInterval        = "core_workload_insertion_retry_interval"
 	InsertionRetryIntervalDefault = int64(3)
 
+	// retry policy applied to every operation
+	RetryMaxAttempts              = "retry.max_attempts"
+	RetryMaxAttemptsDefault       = 1
+	RetryBackoffInitial           = "retry.backoff.initial"
+	RetryBackoffInitialDefault    = "10ms"
+	RetryBackoffMax               = "retry.backoff.max"
+	RetryBackoffMaxDefault        = "1s"
+	RetryBackoffMultiplier        = "retry.backoff.multiplier"
+	RetryBackoffMultiplierDefault = float64(2)
+	RetryBackoffJitter            = "retry.backoff.jitter"
+	RetryBackoffJitterDefault     = float64(0.2)
+
+	// deadline of every attempt of an operation, unset means none
+	TimeoutRead   = "timeout.read"
+	TimeoutScan   = "timeout.scan"
+	TimeoutUpdate = "timeout.update"
+	TimeoutInsert = "timeout.insert"
+	TimeoutDelete = "timeout.delete"
+
+	// abort the run when a threshold is crossed over the evaluation window
+	AbortErrorRate     = "abort.error_rate"
+	AbortP99           = "abort.p99_us"
+	AbortWindow        = "abort.window"
+	AbortWindowDefault = "1m"
+
 	ExponentialPercentile        = "exponential.percentile"
 	ExponentialPercentileDefault = float64(95)
 	ExponentialFrac              = "exponential.frac"
@@ -114,6 +163,28 @@ const (
 	MeasurementType          = "measurementtype"
 	MeasurementTypeDefault   = "histogram"
 	MeasurementRawOutputFile = "measurement.output_file"
+	MeasurementRawGzip       = "measurement.raw.gzip"
+	MeasurementRawRotateMB   = "measurement.raw.rotate_mb"
+	// per measurer output files, measurement.output_file by default
+	MeasurementHistogramOutputFile = "measurement.histogram.output_file"
+	MeasurementCSVOutputFile       = "measurement.raw.output_file"
+	MeasurementHdrLogOutputFile    = "measurement.hdrlog.output_file"
+	// JSON document describing the run, not written by default
+	MeasurementResultFile = "measurement.result_file"
+	// assert.[<OP>.]<stat><=|>=|==|!=<value> checks the final results
+	AssertPrefix = "assert."
+
+	// Bolt file keeping the results of past runs, not kept by default
+	HistoryPath = "history.path"
+	// number of previous runs whose medians are the baseline of a new run
+	HistoryBaselineRuns        = "history.baseline_runs"
+	HistoryBaselineRunsDefault = 5
+	// change in percent above which a stat worse than the baseline is a regression
+	HistoryThreshold        = "history.threshold"
+	HistoryThresholdDefault = 5.0
+	// "op", "intended", "both"
+	MeasurementLatencyMode        = "measurement.latency_mode"
+	MeasurementLatencyModeDefault = "op"
 
 	Command = "command"
 
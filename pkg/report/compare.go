@@ -0,0 +1,218 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// SignificanceLevel is the p-value under which a difference between the
+// intervals of two runs is deemed significant.
+const SignificanceLevel = 0.05
+
+// minIntervals is the number of intervals each run needs for the significance
+// of a difference to be tested.
+const minIntervals = 5
+
+// Run is a result loaded from a file. The result of an interval log only has
+// the operations.
+type Run struct {
+	*measurement.Result
+	Name string
+	// Intervals has the interval histograms of each operation, only the
+	// interval logs have them.
+	Intervals map[string][]*hdrhistogram.Histogram
+}
+
+// Load reads a result document (measurement.result_file) or an interval log
+// (the hdrlog measurement type).
+func Load(name string) (*Run, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var r measurement.Result
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		return &Run{Result: &r, Name: name}, nil
+	}
+
+	intervals, err := measurement.ReadHdrLog(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	// the throughput is computed over the whole log
+	var start, end int64 = math.MaxInt64, 0
+	merged := make(map[string]*hdrhistogram.Histogram, len(intervals))
+	for op, hists := range intervals {
+		merged[op] = hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+		for _, hist := range hists {
+			merged[op].Merge(hist)
+			if hist.StartTimeMs() < start {
+				start = hist.StartTimeMs()
+			}
+			if hist.EndTimeMs() > end {
+				end = hist.EndTimeMs()
+			}
+		}
+	}
+	elapsed := time.Duration(end-start) * time.Millisecond
+	return &Run{
+		Result: &measurement.Result{
+			Operations: measurement.NewOperationResults(merged, func(string) time.Duration { return elapsed }),
+		},
+		Name:      name,
+		Intervals: intervals,
+	}, nil
+}
+
+// compareStats are the stats compared, a higher throughput is better and a
+// higher latency is worse.
+var compareStats = []string{"ops", "p50_us", "p90_us", "p95_us", "p99_us", "p999_us", "p9999_us"}
+
+var statPercentiles = map[string]float64{
+	"p50_us":   50,
+	"p90_us":   90,
+	"p95_us":   95,
+	"p99_us":   99,
+	"p999_us":  99.9,
+	"p9999_us": 99.99,
+}
+
+// Delta is the difference of a stat of an operation between two runs.
+type Delta struct {
+	Operation string
+	Stat      string
+	Base      float64
+	Candidate float64
+	// Change is relative to Base, NaN if Base is 0.
+	Change float64
+	// Regression is set if the stat got worse by more than the threshold.
+	Regression bool
+	// PValue is the probability that the interval values of both runs come
+	// from the same distribution, -1 if they were not tested.
+	PValue float64
+}
+
+// Significant returns whether the difference was tested and is significant.
+func (d Delta) Significant() bool {
+	return d.PValue >= 0 && d.PValue < SignificanceLevel
+}
+
+// Compare returns the deltas of the operations both runs measured, threshold
+// being the relative change above which a worse stat is a regression, eg: 0.05.
+func Compare(base *Run, candidate *Run, threshold float64) []Delta {
+	var deltas []Delta
+	for _, b := range base.Operations {
+		c := candidate.Operation(b.Name)
+		if c == nil {
+			continue
+		}
+		for _, stat := range compareStats {
+			bv, bok := b.Stat(stat)
+			cv, cok := c.Stat(stat)
+			if !bok || !cok {
+				continue
+			}
+			d := Delta{Operation: b.Name, Stat: stat, Base: bv, Candidate: cv, Change: math.NaN(), PValue: -1}
+			if bv != 0 {
+				d.Change = (cv - bv) / bv
+				if stat == "ops" {
+					d.Regression = -d.Change > threshold
+				} else {
+					d.Regression = d.Change > threshold
+				}
+			}
+			x := intervalValues(base.Intervals[b.Name], stat)
+			y := intervalValues(candidate.Intervals[b.Name], stat)
+			if len(x) >= minIntervals && len(y) >= minIntervals {
+				d.PValue = mannWhitneyU(x, y)
+			}
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+// intervalValues returns the value of the stat in each interval.
+func intervalValues(hists []*hdrhistogram.Histogram, stat string) []float64 {
+	values := make([]float64, 0, len(hists))
+	for _, hist := range hists {
+		if stat == "ops" {
+			if length := hist.EndTimeMs() - hist.StartTimeMs(); length > 0 {
+				values = append(values, float64(hist.TotalCount())*1000/float64(length))
+			}
+			continue
+		}
+		values = append(values, float64(hist.ValueAtPercentile(statPercentiles[stat])))
+	}
+	return values
+}
+
+// mannWhitneyU returns the two-sided p-value of the Mann-Whitney U test of x
+// and y, using the normal approximation corrected for ties.
+func mannWhitneyU(x []float64, y []float64) float64 {
+	type value struct {
+		v     float64
+		fromX bool
+	}
+	all := make([]value, 0, len(x)+len(y))
+	for _, v := range x {
+		all = append(all, value{v, true})
+	}
+	for _, v := range y {
+		all = append(all, value{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// rank the values, ties get the average of their ranks
+	var rankX, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromX {
+				rankX += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n1, n2 := float64(len(x)), float64(len(y))
+	n := n1 + n2
+	u := rankX - n1*(n1+1)/2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	// with a continuity correction
+	z := math.Max(math.Abs(u-n1*n2/2)-0.5, 0) / sigma
+	return math.Erfc(z / math.Sqrt2)
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+func TestMannWhitneyU(t *testing.T) {
+	same := []float64{1, 2, 3, 4, 5, 6}
+	if p := mannWhitneyU(same, same); p < 0.9 {
+		t.Errorf("got p=%f for identical samples, want about 1", p)
+	}
+	if p := mannWhitneyU([]float64{7, 7, 7, 7, 7}, []float64{7, 7, 7, 7, 7}); p != 1 {
+		t.Errorf("got p=%f for constant samples, want 1", p)
+	}
+	// no overlap, the exact two-sided p-value is 2/C(12,6) = 0.002
+	if p := mannWhitneyU([]float64{1, 2, 3, 4, 5, 6}, []float64{11, 12, 13, 14, 15, 16}); p > 0.01 {
+		t.Errorf("got p=%f for disjoint samples, want < 0.01", p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := &Run{Result: &measurement.Result{Operations: []measurement.OperationResult{
+		{Name: "READ", Count: 100, OpsPerSec: 1000, P99Us: 1000},
+		{Name: "UPDATE", Count: 100, OpsPerSec: 100},
+		{Name: "SCAN", Count: 10, OpsPerSec: 1},
+	}}}
+	candidate := &Run{Result: &measurement.Result{Operations: []measurement.OperationResult{
+		{Name: "READ", Count: 100, OpsPerSec: 940, P99Us: 1040},
+		{Name: "UPDATE", Count: 100, OpsPerSec: 100, P50Us: 10},
+	}}}
+
+	deltas := make(map[string]Delta)
+	for _, d := range Compare(base, candidate, 0.05) {
+		deltas[d.Operation+"."+d.Stat] = d
+	}
+	if _, ok := deltas["SCAN.ops"]; ok {
+		t.Errorf("got SCAN compared, want only the operations of both runs")
+	}
+	tests := []struct {
+		stat       string
+		change     float64
+		regression bool
+	}{
+		{"READ.ops", -0.06, true},
+		{"READ.p99_us", 0.04, false},
+		{"UPDATE.ops", 0, false},
+		{"UPDATE.p50_us", math.NaN(), false},
+	}
+	for _, test := range tests {
+		d, ok := deltas[test.stat]
+		if !ok {
+			t.Errorf("%s: not compared", test.stat)
+			continue
+		}
+		sameChange := math.Abs(d.Change-test.change) < 1e-9 || (math.IsNaN(d.Change) && math.IsNaN(test.change))
+		if !sameChange || d.Regression != test.regression || d.PValue != -1 {
+			t.Errorf("%s: got %+v, want change %f regression %v", test.stat, d, test.change, test.regression)
+		}
+	}
+}
+
+func writeHdrLog(t *testing.T, name string, latency time.Duration, intervals int) {
+	t.Helper()
+	h := measurement.InitHdrLog("")
+	for i := 0; i < intervals; i++ {
+		for j := 0; j < 100; j++ {
+			h.Measure("READ", time.Now(), latency+time.Duration(j)*time.Microsecond)
+		}
+		h.Summary()
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := h.Output(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadHdrLog(t *testing.T) {
+	dir := t.TempDir()
+	base, candidate := filepath.Join(dir, "base.hlog"), filepath.Join(dir, "candidate.hlog")
+	writeHdrLog(t, base, time.Millisecond, 6)
+	writeHdrLog(t, candidate, 2*time.Millisecond, 6)
+
+	b, err := Load(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(candidate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read := b.Operation("READ"); read == nil || read.Count != 600 || len(b.Intervals["READ"]) != 6 {
+		t.Fatalf("got READ %+v in %d intervals, want 600 in 6 intervals", read, len(b.Intervals["READ"]))
+	}
+
+	for _, d := range Compare(b, c, 0.05) {
+		if d.Stat == "p99_us" && (!d.Regression || !d.Significant()) {
+			t.Errorf("got %+v, want a significant p99 regression", d)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+
+	hdrhistogram "github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/pingcap/go-ycsb/pkg/measurement"
+)
+
+// maxSpectrumX bounds the percentile spectrum to the 99.9999th percentile.
+const maxSpectrumX = 1e6
+
+// timePercentiles are the percentiles plotted over time, with the max.
+var timePercentiles = []struct {
+	name string
+	q    float64
+}{
+	{"p50", 50}, {"p90", 90}, {"p99", 99}, {"p99.9", 99.9},
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; margin: 24px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ccc; padding: 3px 8px; }
+td.num { text-align: right; }
+svg { display: block; margin-bottom: 12px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+{{range .Facts}}<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{if .Operations}}<h2>Summary</h2>
+<table>
+<tr><th>Operation</th><th>Count</th><th>Errors</th><th>OPS</th><th>Avg(us)</th><th>50th(us)</th><th>90th(us)</th><th>99th(us)</th><th>99.9th(us)</th><th>99.99th(us)</th><th>Max(us)</th></tr>
+{{range .Operations}}<tr><td>{{.Name}}</td><td class="num">{{.Count}}</td><td class="num">{{.Errors}}</td><td class="num">{{printf "%.1f" .OpsPerSec}}</td><td class="num">{{printf "%.1f" .AvgUs}}</td><td class="num">{{.P50Us}}</td><td class="num">{{.P90Us}}</td><td class="num">{{.P99Us}}</td><td class="num">{{.P999Us}}</td><td class="num">{{.P9999Us}}</td><td class="num">{{.MaxUs}}</td></tr>
+{{end}}</table>
+{{end}}{{range .Sections}}<h2>{{.Name}}</h2>
+{{range .Charts}}{{.}}
+{{end}}{{end}}{{if .Properties}}<h2>Properties</h2>
+<table>
+{{range .Properties}}<tr><td>{{index . 0}}</td><td>{{index . 1}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+type htmlSection struct {
+	Name   string
+	Charts []template.HTML
+}
+
+type htmlPage struct {
+	Title      string
+	Facts      [][2]string
+	Operations []measurement.OperationResult
+	Sections   []htmlSection
+	Properties [][2]string
+}
+
+// WriteHTML writes a self-contained HTML report of the run: its summary, the
+// throughput and percentiles over time and the percentile spectrum of every
+// operation with intervals, and its properties.
+func WriteHTML(w io.Writer, run *Run) error {
+	page := htmlPage{Title: "go-ycsb report", Operations: run.Operations}
+	if run.Label != "" {
+		page.Title += " - " + run.Label
+	}
+	for _, fact := range [][2]string{
+		{"Run", run.RunID},
+		{"DB", run.DB},
+		{"Workload", run.Workload},
+		{"Command", run.Command},
+		{"Host", run.Host.Hostname},
+	} {
+		if fact[1] != "" {
+			page.Facts = append(page.Facts, fact)
+		}
+	}
+	if !run.StartTime.IsZero() {
+		page.Facts = append(page.Facts,
+			[2]string{"Start", run.StartTime.Format("2006-01-02 15:04:05 MST")},
+			[2]string{"Elapsed", fmt.Sprintf("%.1fs", run.ElapsedSeconds)})
+	}
+	page.Facts = append(page.Facts, [2]string{"Source", run.Name})
+
+	ops := make([]string, 0, len(run.Intervals))
+	for op := range run.Intervals {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	start := runStartMs(run.Intervals)
+	for _, op := range ops {
+		page.Sections = append(page.Sections, operationSection(op, run.Intervals[op], start))
+	}
+
+	keys := make([]string, 0, len(run.Properties))
+	for key := range run.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		page.Properties = append(page.Properties, [2]string{key, run.Properties[key]})
+	}
+
+	return htmlReport.Execute(w, page)
+}
+
+func runStartMs(intervals map[string][]*hdrhistogram.Histogram) int64 {
+	var start int64 = math.MaxInt64
+	for _, hists := range intervals {
+		for _, hist := range hists {
+			if hist.StartTimeMs() < start {
+				start = hist.StartTimeMs()
+			}
+		}
+	}
+	return start
+}
+
+// operationSection returns the charts of an operation, the intervals being
+// placed at their end in seconds since start.
+func operationSection(op string, hists []*hdrhistogram.Histogram, start int64) htmlSection {
+	throughput := series{name: "ops"}
+	percentiles := make([]series, len(timePercentiles)+1)
+	for i, p := range timePercentiles {
+		percentiles[i].name = p.name
+	}
+	percentiles[len(timePercentiles)].name = "max"
+
+	merged := hdrhistogram.New(1, 24*60*60*1000*1000, 3)
+	for _, hist := range hists {
+		merged.Merge(hist)
+		x := float64(hist.EndTimeMs()-start) / 1000
+		if length := hist.EndTimeMs() - hist.StartTimeMs(); length > 0 {
+			throughput.points = append(throughput.points, point{x, float64(hist.TotalCount()) * 1000 / float64(length)})
+		}
+		for i, p := range timePercentiles {
+			percentiles[i].points = append(percentiles[i].points, point{x, float64(hist.ValueAtQuantile(p.q))})
+		}
+		percentiles[len(timePercentiles)].points = append(percentiles[len(timePercentiles)].points, point{x, float64(hist.Max())})
+	}
+
+	// the spectrum goes up to the percentile of the last value
+	maxX := math.Min(math.Max(float64(merged.TotalCount()), 10), maxSpectrumX)
+	spectrum := series{name: op}
+	for i := 0; ; i++ {
+		x := math.Pow(10, float64(i)/20)
+		if x > maxX {
+			break
+		}
+		spectrum.points = append(spectrum.points, point{x, float64(merged.ValueAtQuantile(100 * (1 - 1/x)))})
+	}
+
+	charts := []*chart{
+		{title: op + " throughput", xLabel: "seconds", yLabel: "ops/sec", series: []series{throughput}},
+		{title: op + " latency percentiles", xLabel: "seconds", yLabel: "us", series: percentiles},
+		{title: op + " percentile spectrum", xLabel: "percentile", yLabel: "us", series: []series{spectrum},
+			logX: true, xTicks: spectrumTicks(maxX)},
+	}
+	section := htmlSection{Name: op}
+	for _, c := range charts {
+		section.Charts = append(section.Charts, c.svg())
+	}
+	return section
+}
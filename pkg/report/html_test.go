@@ -0,0 +1,89 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		lo, hi float64
+		n      int
+		want   string
+	}{
+		{0, 9500, 5, "0 2000 4000 6000 8000 10000"},
+		{0, 0.7, 5, "0.0 0.2 0.4 0.6 0.8"},
+		{0, 0, 5, "0.0 0.2 0.4 0.6 0.8 1.0"},
+		{1.5, 12, 8, "0 2 4 6 8 10 12"},
+	}
+	for _, test := range tests {
+		var labels []string
+		for _, tick := range niceTicks(test.lo, test.hi, test.n) {
+			labels = append(labels, tick.label)
+		}
+		if got := strings.Join(labels, " "); got != test.want {
+			t.Errorf("[%v, %v] in %d: got %s, want %s", test.lo, test.hi, test.n, got, test.want)
+		}
+	}
+}
+
+func TestSpectrumTicks(t *testing.T) {
+	var labels []string
+	for _, tick := range spectrumTicks(1e4) {
+		labels = append(labels, tick.label)
+	}
+	if got, want := strings.Join(labels, " "), "0% 90% 99% 99.9% 99.99%"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "run.hlog")
+	writeHdrLog(t, name, time.Millisecond, 3)
+	run, err := Load(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run.Label = "<nightly>"
+	run.Properties = map[string]string{"recordcount": "1000"}
+
+	var b bytes.Buffer
+	if err := WriteHTML(&b, run); err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	for _, want := range []string{
+		"<h2>READ</h2>",
+		"READ throughput",
+		"READ latency percentiles",
+		"READ percentile spectrum",
+		"&lt;nightly&gt;",
+		"<td>recordcount</td><td>1000</td>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if n := strings.Count(html, "<svg"); n != 3 {
+		t.Errorf("got %d charts, want 3", n)
+	}
+	if strings.Contains(html, "NaN") || strings.Contains(html, "<script") {
+		t.Errorf("got NaN coordinates or a script")
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+)
+
+const (
+	chartWidth   = 720
+	chartHeight  = 300
+	marginLeft   = 80
+	marginRight  = 20
+	marginTop    = 36
+	marginBottom = 44
+)
+
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b"}
+
+type point struct {
+	x, y float64
+}
+
+type series struct {
+	name   string
+	points []point
+}
+
+type tick struct {
+	v     float64
+	label string
+}
+
+// chart is a line chart, rendered as an inline SVG.
+type chart struct {
+	title  string
+	xLabel string
+	yLabel string
+	series []series
+	// logX plots the x values, all >= 1, on a log scale with the xTicks.
+	logX   bool
+	xTicks []tick
+}
+
+// niceStep returns the 1, 2 or 5 times a power of 10 closest above raw.
+func niceStep(raw float64) float64 {
+	exp := math.Pow(10, math.Floor(math.Log10(raw)))
+	switch f := raw / exp; {
+	case f <= 1:
+		return exp
+	case f <= 2:
+		return 2 * exp
+	case f <= 5:
+		return 5 * exp
+	default:
+		return 10 * exp
+	}
+}
+
+// niceTicks returns about n evenly spaced round ticks covering [lo, hi].
+func niceTicks(lo float64, hi float64, n int) []tick {
+	if hi <= lo {
+		hi = lo + 1
+	}
+	step := niceStep((hi - lo) / float64(n))
+	decimals := 0
+	if step < 1 {
+		decimals = int(-math.Floor(math.Log10(step)))
+	}
+	var ticks []tick
+	for i := math.Floor(lo / step); i <= math.Ceil(hi/step); i++ {
+		v := i * step
+		ticks = append(ticks, tick{v: v, label: fmt.Sprintf("%.*f", decimals, v)})
+	}
+	return ticks
+}
+
+// svg renders the chart, the y axis starting from 0.
+func (c *chart) svg() template.HTML {
+	scaleX := func(x float64) float64 { return x }
+	if c.logX {
+		scaleX = math.Log10
+	}
+
+	xMin, xMax, yMax := math.Inf(1), math.Inf(-1), 0.0
+	for _, s := range c.series {
+		for _, p := range s.points {
+			xMin = math.Min(xMin, scaleX(p.x))
+			xMax = math.Max(xMax, scaleX(p.x))
+			yMax = math.Max(yMax, p.y)
+		}
+	}
+	if math.IsInf(xMin, 0) {
+		xMin, xMax = 0, 1
+	}
+
+	xTicks := c.xTicks
+	if !c.logX {
+		xTicks = niceTicks(xMin, xMax, 8)
+		xMin, xMax = xTicks[0].v, xTicks[len(xTicks)-1].v
+	}
+	if xMax <= xMin {
+		xMax = xMin + 1
+	}
+	yTicks := niceTicks(0, yMax, 5)
+	yMax = yTicks[len(yTicks)-1].v
+
+	plotWidth := float64(chartWidth - marginLeft - marginRight)
+	plotHeight := float64(chartHeight - marginTop - marginBottom)
+	px := func(x float64) float64 { return marginLeft + (scaleX(x)-xMin)/(xMax-xMin)*plotWidth }
+	py := func(y float64) float64 { return marginTop + plotHeight - y/yMax*plotHeight }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(&b, `<text x="%d" y="16" font-size="13" font-weight="bold">%s</text>`, marginLeft, template.HTMLEscapeString(c.title))
+
+	// grid and axes
+	for _, t := range yTicks {
+		y := py(t.v)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`, marginLeft, y, chartWidth-marginRight, y)
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" dominant-baseline="middle">%s</text>`, marginLeft-6, y, t.label)
+	}
+	for _, t := range xTicks {
+		v := t.v
+		if c.logX {
+			v = math.Pow(10, v)
+		}
+		if scaleX(v) < xMin || scaleX(v) > xMax {
+			continue
+		}
+		x := px(v)
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, x, marginTop, x, marginTop+plotHeight)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`, x, marginTop+plotHeight+14, t.label)
+	}
+	fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%.0f" height="%.0f" fill="none" stroke="#888"/>`, marginLeft, marginTop, plotWidth, plotHeight)
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, marginLeft+plotWidth/2, chartHeight-6, template.HTMLEscapeString(c.xLabel))
+	fmt.Fprintf(&b, `<text transform="translate(14 %.1f) rotate(-90)" text-anchor="middle">%s</text>`, marginTop+plotHeight/2, template.HTMLEscapeString(c.yLabel))
+
+	// series and their legend
+	legendX := float64(chartWidth - marginRight)
+	for i := len(c.series) - 1; i >= 0; i-- {
+		s := c.series[i]
+		color := chartColors[i%len(chartColors)]
+		points := make([]string, 0, len(s.points))
+		for _, p := range s.points {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", px(p.x), py(p.y)))
+		}
+		fmt.Fprintf(&b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, strings.Join(points, " "))
+
+		legendX -= float64(len(s.name))*7 + 24
+		fmt.Fprintf(&b, `<rect x="%.1f" y="22" width="12" height="3" fill="%s"/>`, legendX, color)
+		fmt.Fprintf(&b, `<text x="%.1f" y="27">%s</text>`, legendX+16, template.HTMLEscapeString(s.name))
+	}
+
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+// spectrumTicks returns the ticks of the percentiles of a spectrum plotted up
+// to maxX, the x of a quantile q being 1/(1-q) and the ticks the log10 of x.
+func spectrumTicks(maxX float64) []tick {
+	ticks := []tick{{v: 0, label: "0%"}}
+	for e := 1; float64(e) <= math.Log10(maxX)+1e-9; e++ {
+		label := "90"
+		if e > 1 {
+			label = "99"
+		}
+		if e > 2 {
+			label += "." + strings.Repeat("9", e-2)
+		}
+		ticks = append(ticks, tick{v: float64(e), label: label + "%"})
+	}
+	return ticks
+}
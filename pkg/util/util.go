@@ -14,10 +14,16 @@
 package util
 
 import (
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"math/rand"
 	"os"
 	"sync"
+	"time"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
 )
 
 // Fatalf prints the message and exits the program.
@@ -43,6 +49,24 @@ func RandBytes(r *rand.Rand, b []byte) {
 	}
 }
 
+// NewRand returns the random source of a thread for the given stream, eg: the
+// workload or the DB name. If the seed property is set, the source is derived
+// from the seed, the stream and the thread ID, so runs with the same seed and
+// thread count draw the same values. Otherwise it is seeded with the time.
+func NewRand(p *properties.Properties, stream string, threadID int) *rand.Rand {
+	if _, ok := p.Get(prop.Seed); !ok {
+		return rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[0:8], uint64(p.GetInt64(prop.Seed, 0)))
+	binary.BigEndian.PutUint64(b[8:16], uint64(threadID))
+	hash := fnv.New64a()
+	hash.Write(b[:])
+	hash.Write([]byte(stream))
+	return rand.New(rand.NewSource(int64(hash.Sum64())))
+}
+
 // BufPool is a bytes.Buffer pool
 type BufPool struct {
 	p *sync.Pool
@@ -0,0 +1,48 @@
+// Copyright 2018 PingCAP, Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/magiconair/properties"
+	"github.com/pingcap/go-ycsb/pkg/prop"
+)
+
+func draw(p *properties.Properties, stream string, threadID int) [4]int64 {
+	r := NewRand(p, stream, threadID)
+	var values [4]int64
+	for i := range values {
+		values[i] = r.Int63()
+	}
+	return values
+}
+
+func TestNewRand(t *testing.T) {
+	p := properties.LoadMap(map[string]string{prop.Seed: "42"})
+	if draw(p, "core", 1) != draw(p, "core", 1) {
+		t.Errorf("the same seed, stream and thread should draw the same values")
+	}
+	if draw(p, "core", 1) == draw(p, "core", 2) {
+		t.Errorf("threads should draw different values")
+	}
+	if draw(p, "core", 1) == draw(p, "basic", 1) {
+		t.Errorf("streams should draw different values")
+	}
+
+	other := properties.LoadMap(map[string]string{prop.Seed: "43"})
+	if draw(p, "core", 1) == draw(other, "core", 1) {
+		t.Errorf("seeds should draw different values")
+	}
+}
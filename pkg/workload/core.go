@@ -139,8 +139,8 @@ func (c *core) Load(ctx context.Context, db ycsb.DB, totalCount int64) error {
 }
 
 // InitThread implements the Workload InitThread interface.
-func (c *core) InitThread(ctx context.Context, _ int, _ int) context.Context {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+func (c *core) InitThread(ctx context.Context, threadID int, _ int) context.Context {
+	r := util.NewRand(c.p, "core", threadID)
 	fieldNames := make([]string, len(c.fieldNames))
 	copy(fieldNames, c.fieldNames)
 	state := &coreState{
@@ -441,7 +441,7 @@ func (c *core) doTransactionRead(ctx context.Context, db ycsb.DB, state *coreSta
 func (c *core) doTransactionReadModifyWrite(ctx context.Context, db ycsb.DB, state *coreState) error {
 	start := time.Now()
 	defer func() {
-		measurement.Measure("READ_MODIFY_WRITE", start, time.Now().Sub(start))
+		measurement.MeasureOperation(ctx, "READ_MODIFY_WRITE", start, time.Now().Sub(start))
 	}()
 
 	r := state.r
@@ -651,7 +651,7 @@ func (coreCreator) Create(p *properties.Properties) (ycsb.Workload, error) {
 	var keyrangeLowerBound int64 = insertStart
 	var keyrangeUpperBound int64 = insertStart + insertCount - 1
 
-	c.transactionInsertKeySequence = generator.NewAcknowledgedCounter(c.recordCount)
+	c.transactionInsertKeySequence = generator.NewAcknowledgedCounter(p.GetInt64(prop.TransactionInsertStart, c.recordCount))
 	switch requestDistrib {
 	case "uniform":
 		c.keyChooser = generator.NewUniform(keyrangeLowerBound, keyrangeUpperBound)
@@ -102,6 +102,54 @@ type AnalyzeDB interface {
 	Analyze(ctx context.Context, table string) error
 }
 
+// RetryableDB is the interface for the DB that can tell whether a failed
+// operation may succeed if it is issued again.
+type RetryableDB interface {
+	// IsRetryable returns true if err is transient, eg: a conflict or a timeout.
+	IsRetryable(err error) bool
+}
+
+// ErrorClassifier is the interface for the DB that can tell the class of a
+// failed operation, the failures are broken down by class in the output.
+type ErrorClassifier interface {
+	// ClassifyError returns a short name for the kind of err, eg: an error code
+	// or an HTTP status, or "" if it doesn't know it.
+	ClassifyError(err error) string
+}
+
+// SharedThreadDB is the interface for the DB whose thread state can be used by
+// several operations of a worker at the same time, eg: a handle safe for
+// concurrent use. With async.depth > 1, when the DB is not used as an AsyncDB
+// every in-flight operation runs in a goroutine of its own, and the DBs not
+// implementing it get an InitThread state per in-flight operation.
+type SharedThreadDB interface {
+	// InitSharedThread initializes the state of a worker keeping up to depth
+	// operations in flight. The returned context is used by all of them
+	// concurrently, and is cleaned up with CleanupThread.
+	InitSharedThread(ctx context.Context, threadID int, threadCount int, depth int) context.Context
+}
+
+// AsyncDB is the interface for the DB that can keep several operations of a
+// thread in flight without blocking, eg: by issuing FoundationDB futures or
+// pipelining commands. Every method starts the operation and returns, done is
+// called once it completed, from any goroutine and possibly before the method
+// returned. The operation owns values until done is called. With
+// async.depth > 1 the client issues the operations of a thread through it
+// from a single goroutine.
+type AsyncDB interface {
+	// ReadAsync starts reading a record from the database.
+	ReadAsync(ctx context.Context, table string, key string, fields []string, done func(map[string][]byte, error))
+
+	// UpdateAsync starts updating a record in the database.
+	UpdateAsync(ctx context.Context, table string, key string, values map[string][]byte, done func(error))
+
+	// InsertAsync starts inserting a record in the database.
+	InsertAsync(ctx context.Context, table string, key string, values map[string][]byte, done func(error))
+
+	// DeleteAsync starts deleting a record from the database.
+	DeleteAsync(ctx context.Context, table string, key string, done func(error))
+}
+
 var dbCreators = map[string]DBCreator{}
 
 // RegisterDBCreator registers a creator for the database
@@ -85,7 +85,7 @@ batch.size=${BATCH_SIZE}
 		do
 			echo "Running benchmark"
       export BENCHMARK_NAME="${BENCHMARK_NAME_PREFIX}-run"
-      timeout ${RUNTHREADDURATION} ${BIN_PATH}/go-ycsb run foundationdb -p keyprefix="${KEY_PREFIX}" -p fdb.clusterfile="${FDB_CLUSTER_FILE}" -p fdb.apiversion="${FDB_API_VERSION}" -p fieldcount="${FIELDCOUNT}" -p fieldlength=${FIELDLENGTH} -p fdb.usecachedreadversions=${FDB_USE_CACHED_READ_VERSION} -p fdb.versioncachetime=${FDB_VERSION_CACHE_TIME} -P workloads/dynamic -p threadcount=${RUNTHREADCOUNT}
+      ${BIN_PATH}/go-ycsb run foundationdb -p keyprefix="${KEY_PREFIX}" -p fdb.clusterfile="${FDB_CLUSTER_FILE}" -p fdb.apiversion="${FDB_API_VERSION}" -p fieldcount="${FIELDCOUNT}" -p fieldlength=${FIELDLENGTH} -p fdb.usecachedreadversions=${FDB_USE_CACHED_READ_VERSION} -p fdb.versioncachetime=${FDB_VERSION_CACHE_TIME} -P workloads/dynamic -p threadcount=${RUNTHREADCOUNT} -p maxexecutiontime=${RUNTHREADDURATION}
 			echo "Run completed, sleeping before running again"
 			sleep ${RUNTHREADSLEEPINTERVAL}
 		done
@@ -97,7 +97,7 @@ batch.size=${BATCH_SIZE}
 			do
 				echo "Running benchmark for ${th} thread(s)"
     		export BENCHMARK_NAME="${BENCHMARK_NAME_PREFIX}-run-th${th}"
-        timeout ${RUNTHREADDURATION} ${BIN_PATH}/go-ycsb run foundationdb -p keyprefix="${KEY_PREFIX}" -p fdb.clusterfile="${FDB_CLUSTER_FILE}" -p fdb.apiversion="${FDB_API_VERSION}" -p fieldcount="${FIELDCOUNT}" -p fieldlength=${FIELDLENGTH} -p fdb.usecachedreadversions=${FDB_USE_CACHED_READ_VERSION} -p fdb.versioncachetime=${FDB_VERSION_CACHE_TIME} -P workloads/dynamic -p threadcount=${th}
+        ${BIN_PATH}/go-ycsb run foundationdb -p keyprefix="${KEY_PREFIX}" -p fdb.clusterfile="${FDB_CLUSTER_FILE}" -p fdb.apiversion="${FDB_API_VERSION}" -p fieldcount="${FIELDCOUNT}" -p fieldlength=${FIELDLENGTH} -p fdb.usecachedreadversions=${FDB_USE_CACHED_READ_VERSION} -p fdb.versioncachetime=${FDB_VERSION_CACHE_TIME} -P workloads/dynamic -p threadcount=${th} -p maxexecutiontime=${RUNTHREADDURATION}
 				sleep ${RUNTHREADSLEEPINTERVAL}
 			done
 		done
@@ -150,7 +150,7 @@ WORKLOAD_S3="recordcount=${RECORDCOUNT}
 		do
 			echo "Running benchmark"
       export BENCHMARK_NAME="${BENCHMARK_NAME_PREFIX}-run"
-      timeout ${RUNTHREADDURATION} ${BIN_PATH}/go-ycsb run s3 -p s3.bucket=${S3_BUCKET} -p s3.endpoint=${S3_ENDPOINT} -p s3.access_key=${AWS_ACCESS_KEY_ID} -p s3.secret_key=${AWS_SECRET_ACCESS_KEY} -p s3.use_path_style=${S3_USE_PATH_STYLE} -p fieldcount="${FIELDCOUNT}" -p fieldlength=${FIELDLENGTH} -P workloads/dynamic -p threadcount=${RUNTHREADCOUNT}
+      ${BIN_PATH}/go-ycsb run s3 -p s3.bucket=${S3_BUCKET} -p s3.endpoint=${S3_ENDPOINT} -p s3.access_key=${AWS_ACCESS_KEY_ID} -p s3.secret_key=${AWS_SECRET_ACCESS_KEY} -p s3.use_path_style=${S3_USE_PATH_STYLE} -p fieldcount="${FIELDCOUNT}" -p fieldlength=${FIELDLENGTH} -P workloads/dynamic -p threadcount=${RUNTHREADCOUNT} -p maxexecutiontime=${RUNTHREADDURATION}
 			echo "Run completed, sleeping before running again"
 			sleep ${RUNTHREADSLEEPINTERVAL}
 		done